@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultAdminPermission 管理端點預設要求的權限
+const DefaultAdminPermission = "admin:users:write"
+
+// AdminHandlers 管理用戶狀態與強制登出的 Gin 處理器
+// 讓各服務提供一致的管理 API，不必各自包裝客戶端方法
+type AdminHandlers struct {
+	authClient AuthClient
+	middleware *GinMiddleware
+	logger     *zap.Logger
+}
+
+// SetUserStatusRequest 設置用戶狀態的請求內容
+type SetUserStatusRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+// NewAdminHandlers 建立管理處理器，middleware 用於驗證身份與檢查管理權限
+func NewAdminHandlers(authClient AuthClient, middleware *GinMiddleware, logger *zap.Logger) *AdminHandlers {
+	return &AdminHandlers{
+		authClient: authClient,
+		middleware: middleware,
+		logger:     logger,
+	}
+}
+
+// Register 將管理端點掛載到路由群組，並要求 permission 權限（為空時使用 DefaultAdminPermission）
+//
+//	PUT    /users/:user_id/status        設置用戶狀態
+//	POST   /users/:user_id/force-logout  強制登出
+//	DELETE /users/:user_id/force-logout  清除強制登出（authClient 須實作 ForceLogoutClearer，否則回應 501）
+func (h *AdminHandlers) Register(rg *gin.RouterGroup, permission string) {
+	if permission == "" {
+		permission = DefaultAdminPermission
+	}
+
+	admin := rg.Group("", h.middleware.Authenticate(), h.middleware.RequirePermission(permission))
+	admin.PUT("/users/:user_id/status", h.SetUserStatusHandler())
+	admin.POST("/users/:user_id/force-logout", h.ForceLogoutHandler())
+	admin.DELETE("/users/:user_id/force-logout", h.ClearForceLogoutHandler())
+}
+
+// SetUserStatusHandler 設置用戶啟用狀態
+func (h *AdminHandlers) SetUserStatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
+
+		var req SetUserStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+
+		if err := h.authClient.SetUserStatus(c.Request.Context(), userID, *req.IsActive); err != nil {
+			if errors.Is(err, ErrStaleUserStatus) {
+				response.Error(c, http.StatusConflict, "CONFLICT", "A newer user status has already been set")
+				return
+			}
+			h.logger.Error("Failed to set user status",
+				zap.String("user_id", userID),
+				zap.Error(err))
+			response.InternalServerError(c, "Failed to set user status")
+			return
+		}
+
+		h.logger.Info("User status updated by admin",
+			zap.String("user_id", userID),
+			zap.Bool("is_active", *req.IsActive),
+			zap.String("operator_id", h.middleware.getUserID(c)))
+
+		response.Success(c, gin.H{"user_id": userID, "is_active": *req.IsActive}, "User status updated")
+	}
+}
+
+// ForceLogoutHandler 強制登出用戶，使其現有 token 失效
+func (h *AdminHandlers) ForceLogoutHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
+
+		if err := h.authClient.SetForceLogout(c.Request.Context(), userID); err != nil {
+			h.logger.Error("Failed to set force logout",
+				zap.String("user_id", userID),
+				zap.Error(err))
+			response.InternalServerError(c, "Failed to force logout user")
+			return
+		}
+
+		h.logger.Info("User force logged out by admin",
+			zap.String("user_id", userID),
+			zap.String("operator_id", h.middleware.getUserID(c)))
+
+		response.Success(c, gin.H{"user_id": userID}, "User force logged out")
+	}
+}
+
+// ClearForceLogoutHandler 清除用戶的強制登出標記
+func (h *AdminHandlers) ClearForceLogoutHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("user_id")
+
+		clearer, ok := h.authClient.(ForceLogoutClearer)
+		if !ok {
+			response.Error(c, http.StatusNotImplemented, "NOT_IMPLEMENTED", "Clearing force logout is not supported")
+			return
+		}
+
+		if err := clearer.ClearForceLogout(c.Request.Context(), userID); err != nil {
+			h.logger.Error("Failed to clear force logout",
+				zap.String("user_id", userID),
+				zap.Error(err))
+			response.InternalServerError(c, "Failed to clear force logout")
+			return
+		}
+
+		h.logger.Info("Force logout cleared by admin",
+			zap.String("user_id", userID),
+			zap.String("operator_id", h.middleware.getUserID(c)))
+
+		response.Success(c, gin.H{"user_id": userID}, "Force logout cleared")
+	}
+}
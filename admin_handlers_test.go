@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// clearingAuthClient 另實作 ForceLogoutClearer 的 stubAuthClient
+type clearingAuthClient struct {
+	*stubAuthClient
+	cleared []string
+}
+
+func (c *clearingAuthClient) ClearForceLogout(_ context.Context, userID string) error {
+	c.cleared = append(c.cleared, userID)
+	return nil
+}
+
+func TestClearForceLogoutHandler(t *testing.T) {
+	stub := &stubAuthClient{results: map[string]*AuthResult{"admin": activeResult("1", DefaultAdminPermission)}}
+	clearing := &clearingAuthClient{stubAuthClient: stub}
+
+	tests := []struct {
+		name       string
+		client     AuthClient
+		wantStatus int
+	}{
+		{name: "client without ForceLogoutClearer", client: stub, wantStatus: http.StatusNotImplemented},
+		{name: "client with ForceLogoutClearer", client: clearing, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			m := NewGinMiddleware(tt.client, zap.NewNop())
+			NewAdminHandlers(tt.client, m, zap.NewNop()).Register(router.Group("/admin"), "")
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/users/42/force-logout", nil)
+			req.Header.Set("Authorization", "Bearer admin")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+
+	if len(clearing.cleared) != 1 || clearing.cleared[0] != "42" {
+		t.Errorf("cleared = %q, want [42]", clearing.cleared)
+	}
+}
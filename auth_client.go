@@ -3,24 +3,48 @@
 package auth
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"strconv"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrCorruptCache 快取內容格式錯誤，無法解析
+	ErrCorruptCache = errors.New("corrupt cache entry")
+	// ErrCacheNotFound 快取不存在
+	ErrCacheNotFound = errors.New("cache entry not found")
+	// ErrCacheTimestampMissing 快取內容未包含寫入時間
+	ErrCacheTimestampMissing = errors.New("cache entry has no timestamp")
+	// ErrMissingIssuedAt token 缺少簽發時間，無法與強制登出時間比較
+	ErrMissingIssuedAt = errors.New("token issued-at is missing")
+	// ErrStaleUserStatus 寫入的用戶狀態不比現有狀態新，未覆蓋
+	ErrStaleUserStatus = errors.New("user status is not newer than the stored one")
+)
+
 // AuthClient 統一身份驗證客戶端介面
 type AuthClient interface {
 	// JWT 驗證
@@ -37,6 +61,30 @@ type AuthClient interface {
 	SetForceLogout(ctx context.Context, userID string) error
 }
 
+// ForceLogoutClearer 可清除強制登出標記的 AuthClient（選用），*Client 已實作
+// AdminHandlers 的清除強制登出端點需要 authClient 實作此介面
+type ForceLogoutClearer interface {
+	ClearForceLogout(ctx context.Context, userID string) error
+}
+
+// TokenRefresher 可透過 Auth 服務換發 token 的 AuthClient（選用），*Client 已實作
+// GinMiddleware.AutoRefresh 需要 authClient 實作此介面，未實作時視為刷新失敗
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
+}
+
+// TokenTypeValidator 可依 token_type 區分 access 與 refresh token 的 AuthClient（選用），*Client 已實作
+type TokenTypeValidator interface {
+	ValidateAccessToken(tokenString string) (*Claims, error)
+	ValidateRefreshToken(tokenString string) (*Claims, error)
+}
+
+// Token 類型（對應 Claims.TokenType）
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // Claims JWT 聲明結構
 type Claims struct {
 	UserID      string   `json:"user_id"`
@@ -45,6 +93,7 @@ type Claims struct {
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
 	TokenType   string   `json:"token_type"`
+	TenantID    string   `json:"tenant_id,omitempty"` // 多租戶部署中 token 所屬的租戶
 	jwt.RegisteredClaims
 }
 
@@ -54,6 +103,16 @@ type AuthResult struct {
 	DynamicPermissions  []string `json:"dynamic_permissions"`
 	IsActive            bool     `json:"is_active"`
 	ShouldForceLogout   bool     `json:"should_force_logout"`
+	ValidatedFromCache  bool     `json:"validated_from_cache"` // 由驗證快取（ValidationCacheTTL）回傳，僅重新檢查強制登出
+
+	degraded bool // 任一檢查失敗而採用容錯預設值，此結果不寫入驗證快取
+}
+
+// TokenPair Auth 服務換發的 Token 組
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token 有效秒數
 }
 
 // UserStatus 用戶狀態結構
@@ -64,72 +123,478 @@ type UserStatus struct {
 
 // Config 客戶端配置
 type Config struct {
-	PublicKeyPath string        // JWT 公鑰路徑
+	PublicKeyPath string        // JWT 公鑰路徑（設定 JWKSURL 時可省略）
 	Issuer        string        // JWT 發行者
+	IssuerPattern string        // 發行者正規表示式（完整比對），例如 `auth\.prod(\..+)?`；與 Issuer 任一相符即通過
 	RedisAddr     string        // Redis 地址
 	RedisPassword string        // Redis 密碼
 	RedisDB       int           // Redis 資料庫
-	AuthServiceURL string       // Auth 服務 URL（備用）
+	AuthServiceURL string       // Auth 服務 URL，用於刷新 token，並作為主要儲存失敗時的 HTTP 降級查詢（見 HTTPStore、DisableHTTPFallback）
 	Logger        *zap.Logger   // 日誌記錄器
+
+	DeleteCorruptCache bool          // 快取內容無法解析時是否刪除該 key，讓上游重新寫入
+	DynamicAuthTimeout time.Duration // ValidateTokenWithDynamicAuth 整體時間預算（0 表示不限制）
+	ExpectedTokenTyp   string        // 要求 JWT typ 標頭等於此值（如 JWT、at+jwt），為空時不檢查
+	KeyID              string        // 公鑰的 key ID；設定後 token 帶有 kid 標頭時必須相符
+
+	AllowedTokenSchemes []string // ValidateToken 接受的驗證方案前綴，預設僅 Bearer
+
+	// MaxPermissions 單一權限集合的數量上限，超出時截斷並記錄警告（0 表示不限制）
+	// 防止權限數量膨脹拖慢權限比對或成為 DoS 攻擊途徑
+	MaxPermissions int
+
+	// TokenPreprocessor 在解析前將合作夥伴特殊編碼的 token 轉換為標準 JWT（nil 表示不處理）
+	TokenPreprocessor func(raw string) (string, error)
+
+	// RedisClient 外部提供的 Redis 客戶端（如共用的 *redis.Client 或 *redis.ClusterClient）
+	// 設定後不再依 RedisAddr 建立連線，且 Close 不會關閉此客戶端，生命週期由呼叫端負責
+	RedisClient redis.Cmdable
+
+	// RedisReadAddr Redis 唯讀副本地址，CheckUserStatus/CheckForceLogout/GetUserDynamicPermissions 等查詢改由副本處理，
+	// 寫入仍送往主節點；未設定時一律使用主節點。副本同步有延遲，剛設置的停用或強制登出可能短暫未生效
+	// 僅適用於 single 模式
+	RedisReadAddr string
+
+	// RedisKeyPrefix 所有 Redis key 的前綴（如 "portal:"），須與寫入這些 key 的 Auth 服務一致；key 格式見 RedisKeys
+	// 僅適用於預設的 RedisStore
+	RedisKeyPrefix string
+
+	// RewritePermissionsOnRead 讀到非標準格式（純陣列、逗號分隔字串等）的動態權限時，以標準包裝物件格式寫回並保留原 TTL
+	// 讓各寫入端格式不一的快取自行收斂；預設關閉以避免讀取時產生非預期的寫入，僅適用於預設的 RedisStore
+	RewritePermissionsOnRead bool
+
+	// SigningAlgorithms 接受的 JWT 簽名演算法（如 RS256、ES256、EdDSA），預設為 RS256/RS384/RS512
+	// none 與 HMAC（HS*）演算法一律拒絕，即使列於此處，以防演算法混淆攻擊
+	SigningAlgorithms []string
+
+	// SkipForceLogoutWithoutIssuedAt token 缺少簽發時間（iat <= 0）時是否略過強制登出比較
+	// 預設回傳 ErrMissingIssuedAt，避免缺少 iat 的新工作階段被誤判為強制登出
+	SkipForceLogoutWithoutIssuedAt bool
+
+	// ExclusiveForceLogoutBoundary 強制登出時間等於 token 簽發時間時視為有效（>）
+	// 預設（false）為包含邊界（>=）：兩者皆為秒級精度，與強制登出同一秒簽發的 token 無從判斷先後，一律失效；
+	// 代價是強制登出當下同一秒內重新登入取得的 token 也會失效，需再登入一次
+	ExclusiveForceLogoutBoundary bool
+
+	// DefaultUserActive 用戶狀態快取不存在時 CheckUserStatus 的回傳值（nil 表示啟用，維持相容）
+	// 用戶須明確寫入狀態快取的服務可設為 false，讓缺少狀態的用戶視為停用
+	DefaultUserActive *bool
+
+	// JWKSURL JWKS 端點；設定後改由此端點取得公鑰並依 token 的 kid 標頭選擇，取代 PublicKeyPath
+	// 遇到未知 kid 時會立即重新抓取；啟動時抓取失敗會在背景退避重試，不會讓 NewClient 失敗
+	JWKSURL string
+	// JWKSRefreshInterval JWKS 背景刷新間隔，預設 15 分鐘
+	JWKSRefreshInterval time.Duration
+
+	// ClockSkew 驗證 exp 與 nbf 時容許的時鐘誤差，預設 0（嚴格比對）
+	// 主機間時鐘不同步時建議設為 30 秒
+	ClockSkew time.Duration
+
+	// ExpectedAudience 本服務接受的受眾（如內部名稱與對外主機名），token 的 aud 聲明（字串或陣列）
+	// 須至少包含其中一個，避免發給其他服務的 token 被接受（為空時不檢查）
+	ExpectedAudience []string
+
+	// TrustedProxies 信任的代理 IP 或 CIDR，用於從 X-Forwarded-For 解析真實用戶端 IP（見 ClientIPResolver）
+	// 僅應列出自家負載平衡器；列入不受控的位址會讓用戶端得以偽造 IP
+	TrustedProxies []string
+
+	// ValidationCacheTTL ValidateTokenWithDynamicAuth 成功結果的記憶體快取時間（0 表示停用）
+	// 快取命中時仍會讀取強制登出標記，其他檢查不重新查詢 Redis：
+	// 用戶停用與動態權限變更最多延遲一個 TTL 才生效（經由本客戶端設置者會立即清除）
+	ValidationCacheTTL time.Duration
+	// ValidationCacheSize 驗證快取的最大項目數，預設 10000
+	ValidationCacheSize int
+
+	// NegativeCacheTTL 無效 token 驗證失敗結果的記憶體快取時間（0 表示停用），建議數秒
+	// 猜測 token 的攻擊重送相同無效 token 時直接回傳快取的錯誤，不再重複解析與驗證簽名
+	// 只快取不會隨時間變為有效的錯誤（簽名不符、格式錯誤、已過期、發行者/受眾/類型不符）：
+	// token 內容與簽名固定，這些結果不會改變；尚未生效（nbf）、找不到 kid（JWKS 可能稍後載入）、
+	// 超出同時驗證上限與動態檢查失敗都可能在重試時成功，因此不快取
+	NegativeCacheTTL time.Duration
+	// NegativeCacheSize 無效 token 快取的最大項目數，預設 10000；超出時淘汰最久未使用的項目
+	NegativeCacheSize int
+
+	// RedisMode Redis 部署模式：single（預設）、cluster 或 sentinel
+	RedisMode string
+	// RedisAddrs cluster 模式的節點地址或 sentinel 模式的 Sentinel 地址（未設定時沿用 RedisAddr）
+	RedisAddrs []string
+	// RedisMasterName sentinel 模式的 master 名稱
+	RedisMasterName string
+	// RedisSentinelPassword Sentinel 節點本身的密碼（與資料節點的 RedisPassword 不同時設定）
+	RedisSentinelPassword string
+
+	// MaxConcurrentValidations 同時進行的 JWT 簽名驗證上限，避免流量尖峰時 RSA 運算耗盡 CPU（0 表示不限制）
+	// 名額於取得公鑰後才佔用，JWKS 刷新的網路請求不會佔住名額
+	MaxConcurrentValidations int
+	// ValidationQueueTimeout 達到上限時等待空位的時間，逾時回傳 ErrValidationOverloaded（0 表示立即失敗）
+	ValidationQueueTimeout time.Duration
+
+	// Store 用戶狀態、強制登出與動態權限的儲存後端（如自家 gRPC 服務或測試替身）
+	// 為 nil 時使用以 Redis 連線建立的 RedisStore；DeleteCorruptCache 與 RedisReadAddr 僅適用於預設的 RedisStore
+	// 設定 Store 且未提供 RedisClient 時不建立 Redis 連線，FailureSink 等直接使用 Redis 的功能回傳 ErrRedisNotConfigured
+	Store PermissionStore
+
+	// FallbackStores 主要儲存（Store 或預設的 RedisStore）與 HTTP 降級層之後依序嘗試的降級層
+	// 所有層都失敗時才採用 JWT 權限與容錯預設值
+	// 各層使用統計可由 StoreStats 取得
+	FallbackStores []StoreTier
+	// DisableHTTPFallback 設定 AuthServiceURL 時不加入 HTTP 降級層（Auth 服務未提供查詢端點時使用）
+	// 預設在主要儲存之後查詢 AuthServiceURL（見 HTTPStore）
+	DisableHTTPFallback bool
+
+	// AuthServiceHealthPath CheckHealth 檢查 Auth 服務時請求的路徑（相對於 AuthServiceURL），預設 /health
+	AuthServiceHealthPath string
+
+	// FailClosed 無法確認用戶狀態或強制登出標記時（所有儲存層皆失敗、超出 DynamicAuthTimeout），
+	// ValidateTokenWithDynamicAuth 回傳 ErrDynamicAuthUnavailable 而非放行
+	// 預設（false）為容錯放行：Redis 故障時服務不中斷，但已停用或被強制登出的用戶可能在故障期間通過驗證
+	// 啟用後安全性較高，但 Redis 故障會直接造成所有需驗證的請求失敗；動態權限查詢失敗時仍改用 JWT 權限
+	FailClosed bool
+
+	// TracerProvider 建立 OpenTelemetry span 的來源，涵蓋 ValidateTokenWithDynamicAuth 與各項儲存查詢
+	// 未設定時使用 otel 全域 provider（未註冊時為 no-op）；span 屬性包含 user_id 與快取命中狀態，不含 token
+	TracerProvider trace.TracerProvider
+
+	// Issuers 多發行者設定：各發行者各自的金鑰與簽名演算法，iss 相符的 token 只以該發行者的設定驗證，
+	// 金鑰或演算法不符即拒絕。未列出的發行者仍依 Issuer/IssuerPattern 與全域金鑰驗證；
+	// 未設定 PublicKeyPath 與 JWKSURL 時只接受列出的發行者
+	Issuers []IssuerConfig
 }
 
 // Client 身份驗證客戶端實作
 type Client struct {
 	config     *Config
 	publicKey  interface{}
-	redisClient *redis.Client
+	jwks          *jwksProvider // 設定 JWKSURL 時使用，依 kid 取得公鑰
+	issuerPattern *regexp.Regexp
+	algorithms    []string               // 允許的簽名演算法（已排除 none 與 HMAC）
+	issuers       map[string]*issuerKeys // Issuers 設定的各發行者金鑰（nil 表示未設定）
+	validMethods  []string               // 全域與各發行者演算法的聯集
+	defaultKey    bool                   // 是否設定全域金鑰（PublicKeyPath 或 JWKSURL）
+	redisClient   redis.Cmdable          // 主節點，供 FailureSink 等直接使用 Redis 的元件
+	readClient    redis.Cmdable          // 唯讀副本（未設定 RedisReadAddr 時與 redisClient 相同）
+	store         PermissionStore        // 用戶狀態、強制登出與動態權限的存取
+	closers       []io.Closer            // 由 SDK 建立、關閉時需釋放的連線
 	httpClient  *http.Client
+	ipResolver    *ClientIPResolver
+	validations   *lruCache[string, *AuthResult] // 驗證結果快取（nil 表示停用）
+	rejections    *lruCache[string, error]       // 無效 token 快取（nil 表示停用）
+	validationSem chan struct{}                  // 限制同時驗證數量（nil 表示不限制）
+	inFlight      atomic.Int64                   // 進行中的驗證數
+	tracer        trace.Tracer
 	logger     *zap.Logger
+
+	mu       sync.Mutex
+	buffered []bufferedComponent // Shutdown 時需清空的元件
+	closed   bool
 }
 
 // NewClient 建立新的身份驗證客戶端
 func NewClient(config *Config) (*Client, error) {
-	// 載入 JWT 公鑰
-	publicKey, err := loadPublicKey(config.PublicKeyPath)
+	// 載入 JWT 公鑰（使用 JWKS 時改於稍後建立金鑰來源）
+	// 僅設定 Issuers 時可不設定全域金鑰
+	var publicKey interface{}
+	var err error
+	defaultKey := config.JWKSURL != "" || config.PublicKeyPath != "" || len(config.Issuers) == 0
+	if config.JWKSURL == "" && defaultKey {
+		publicKey, err = loadPublicKey(config.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load public key: %w", err)
+		}
+	}
+
+	// 整理允許的簽名演算法
+	algorithms, err := signingAlgorithms(config.SigningAlgorithms)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load public key: %w", err)
+		return nil, err
 	}
 
-	// 初始化 Redis 客戶端
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     config.RedisAddr,
-		Password: config.RedisPassword,
-		DB:       config.RedisDB,
-	})
+	// 編譯發行者規則
+	var issuerPattern *regexp.Regexp
+	if config.IssuerPattern != "" {
+		issuerPattern, err = regexp.Compile("^(?:" + config.IssuerPattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid issuer pattern: %w", err)
+		}
+	}
 
-	// 測試 Redis 連接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		config.Logger.Warn("Redis connection failed, will use fallback methods", zap.Error(err))
+	// 建立用戶端 IP 解析器
+	ipResolver, err := NewClientIPResolver(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化 Redis 客戶端（外部提供時直接沿用；設定 Store 且未提供 RedisClient 時不建立連線）
+	// 之後任一步驟失敗時釋放已建立的連線與 JWKS 背景刷新
+	var closers []io.Closer
+	created := false
+	defer func() {
+		if !created {
+			for _, closer := range closers {
+				closer.Close()
+			}
+		}
+	}()
+	redisClient := config.RedisClient
+	if redisClient == nil && config.Store == nil {
+		client, err := newRedisClient(config)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, client)
+		redisClient = client
+
+		// 測試 Redis 連接
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			config.Logger.Warn("Redis connection failed, will use fallback methods", zap.Error(err))
+		}
+	}
+
+	// 初始化唯讀副本（未設定時查詢沿用主節點；僅供預設的 RedisStore 使用）
+	readClient := redisClient
+	if config.RedisReadAddr != "" && config.Store == nil {
+		if config.RedisMode != "" && config.RedisMode != RedisModeSingle {
+			return nil, errors.New("RedisReadAddr is only supported in single redis mode")
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.RedisReadAddr,
+			Password: config.RedisPassword,
+			DB:       config.RedisDB,
+		})
+		closers = append(closers, client)
+		readClient = client
+	}
+
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+
+	// 初始化 JWKS 金鑰來源
+	var jwks *jwksProvider
+	if config.JWKSURL != "" {
+		jwks = newJWKSProvider(config.JWKSURL, config.JWKSRefreshInterval, httpClient, config.Logger)
+		closers = append(closers, jwks)
+	}
+
+	// 載入各發行者的金鑰
+	issuers, issuerClosers, err := newIssuerKeys(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	closers = append(closers, issuerClosers...)
+
+	// 初始化驗證結果快取
+	var validations *lruCache[string, *AuthResult]
+	if config.ValidationCacheTTL > 0 {
+		size := config.ValidationCacheSize
+		if size <= 0 {
+			size = 10000
+		}
+		validations = newLRUCache[string, *AuthResult](size)
+	}
+
+	// 初始化無效 token 快取
+	var rejections *lruCache[string, error]
+	if config.NegativeCacheTTL > 0 {
+		size := config.NegativeCacheSize
+		if size <= 0 {
+			size = 10000
+		}
+		rejections = newLRUCache[string, error](size)
+	}
+
+	// 初始化權限儲存（預設使用 Redis）
+	store := config.Store
+	if store == nil {
+		store = NewRedisStore(redisClient, RedisStoreConfig{
+			ReadClient:               readClient,
+			DeleteCorruptCache:       config.DeleteCorruptCache,
+			RewritePermissionsOnRead: config.RewritePermissionsOnRead,
+			KeyPrefix:                config.RedisKeyPrefix,
+			Logger:                   config.Logger,
+		})
+	}
+
+	// 組成降級鏈：主要儲存 → Auth 服務 HTTP API → 自訂降級層
+	var fallbacks []StoreTier
+	if config.AuthServiceURL != "" && !config.DisableHTTPFallback {
+		fallbacks = append(fallbacks, StoreTier{
+			Name:     "http",
+			Store:    NewHTTPStore(config.AuthServiceURL, httpClient),
+			Timeout:  defaultTierTimeout,
+			Cooldown: defaultTierCooldown,
+		})
+	}
+	fallbacks = append(fallbacks, config.FallbackStores...)
+	if len(fallbacks) > 0 {
+		primaryName := "redis"
+		if config.Store != nil {
+			primaryName = "primary"
+		}
+		primary := StoreTier{Name: primaryName, Store: store, Timeout: defaultTierTimeout, Cooldown: defaultTierCooldown}
+		tiers := append([]StoreTier{primary}, fallbacks...)
+		fallback, err := NewFallbackStore(config.Logger, tiers...)
+		if err != nil {
+			return nil, err
+		}
+		store = fallback
+	}
+
+	var validationSem chan struct{}
+	if config.MaxConcurrentValidations > 0 {
+		validationSem = make(chan struct{}, config.MaxConcurrentValidations)
 	}
 
+	created = true
 	return &Client{
-		config:      config,
-		publicKey:   publicKey,
-		redisClient: redisClient,
-		httpClient:  &http.Client{Timeout: 5 * time.Second},
-		logger:      config.Logger,
+		config:        config,
+		publicKey:     publicKey,
+		jwks:          jwks,
+		issuerPattern: issuerPattern,
+		algorithms:    algorithms,
+		issuers:       issuers,
+		validMethods:  validMethods(algorithms, issuers),
+		defaultKey:    defaultKey,
+		redisClient:   redisClient,
+		readClient:    readClient,
+		store:         store,
+		closers:       closers,
+		httpClient:    httpClient,
+		ipResolver:    ipResolver,
+		validations:   validations,
+		rejections:    rejections,
+		validationSem: validationSem,
+		tracer:        newTracer(config.TracerProvider),
+		logger:        config.Logger,
 	}, nil
 }
 
-// ValidateToken 驗證 JWT Token
+// IPResolver 回傳依 Config.TrustedProxies 建立的用戶端 IP 解析器
+func (c *Client) IPResolver() *ClientIPResolver {
+	return c.ipResolver
+}
+
+// RedisClient 回傳客戶端使用的 Redis 主節點連線，供限流等元件共用；生命週期仍由 Client 管理
+// 設定 Store 且未提供 Config.RedisClient 時回傳 nil
+func (c *Client) RedisClient() redis.Cmdable {
+	return c.redisClient
+}
+
+// RedisKeys 依 Config.RedisKeyPrefix 組成 Redis key，供直接讀寫 Redis 的元件使用
+func (c *Client) RedisKeys() RedisKeys {
+	return RedisKeys{Prefix: c.config.RedisKeyPrefix}
+}
+
+// ValidateToken 驗證 JWT Token，等同 ValidateAccessToken
 func (c *Client) ValidateToken(tokenString string) (*Claims, error) {
-	// 移除 Bearer 前綴
-	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+	return c.ValidateAccessToken(tokenString)
+}
+
+// ValidateAccessToken 驗證 access token，拒絕 refresh token 等其他類型
+// 未帶 token_type 的舊版 token 視為 access token
+func (c *Client) ValidateAccessToken(tokenString string) (*Claims, error) {
+	claims, err := c.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != "" && claims.TokenType != TokenTypeAccess {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrWrongTokenType, TokenTypeAccess, claims.TokenType)
+	}
+	return claims, nil
+}
+
+// ValidateRefreshToken 驗證 refresh token，token_type 必須為 refresh
+func (c *Client) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := c.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrWrongTokenType, TokenTypeRefresh, claims.TokenType)
+	}
+	return claims, nil
+}
+
+// InFlightValidations 回傳目前進行中的 JWT 簽名驗證數，可作為負載指標（不含等待 JWKS 取得金鑰的請求）
+func (c *Client) InFlightValidations() int64 {
+	return c.inFlight.Load()
+}
+
+// acquireValidation 取得驗證名額；達到上限時依 ValidationQueueTimeout 等待或立即失敗
+func (c *Client) acquireValidation() (func(), error) {
+	if c.validationSem != nil {
+		select {
+		case c.validationSem <- struct{}{}:
+		default:
+			if c.config.ValidationQueueTimeout <= 0 {
+				return nil, ErrValidationOverloaded
+			}
+			timer := time.NewTimer(c.config.ValidationQueueTimeout)
+			defer timer.Stop()
+			select {
+			case c.validationSem <- struct{}{}:
+			case <-timer.C:
+				return nil, ErrValidationOverloaded
+			}
+		}
+	}
+
+	c.inFlight.Add(1)
+	return func() {
+		c.inFlight.Add(-1)
+		if c.validationSem != nil {
+			<-c.validationSem
+		}
+	}, nil
+}
+
+// parseToken 解析並驗證 JWT Token 的簽名與聲明，不檢查 token 類型
+func (c *Client) parseToken(tokenString string) (*Claims, error) {
+	// 移除驗證方案前綴（如 Bearer）
+	tokenString, err := c.stripTokenScheme(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合作夥伴 token 前處理
+	if c.config.TokenPreprocessor != nil {
+		tokenString, err = c.config.TokenPreprocessor(tokenString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preprocess token: %w", err)
+		}
+	}
+
+	// 限制同時進行的簽名驗證：取得公鑰後才佔用名額，JWKS 網路請求期間不佔用
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
 
 	// 解析 Token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 驗證簽名方法
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		// 驗證 typ 標頭，防止 token 類型混用
+		if c.config.ExpectedTokenTyp != "" && !matchesTokenTyp(token.Header["typ"], c.config.ExpectedTokenTyp) {
+			return nil, fmt.Errorf("unexpected token typ: %v", token.Header["typ"])
+		}
+		publicKey, err := c.resolveKey(token)
+		if err != nil {
+			return nil, err
+		}
+		// 驗證簽名方法與公鑰類型相符
+		if !matchesKeyType(token.Method, publicKey) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return c.publicKey, nil
-	})
+		if release, err = c.acquireValidation(); err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods(c.validMethods), jwt.WithLeeway(c.config.ClockSkew))
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return nil, mapParseError(err)
 	}
 
 	// 驗證 Token 有效性
@@ -139,15 +604,351 @@ func (c *Client) ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	// 驗證發行者
-	if claims.Issuer != c.config.Issuer {
-		return nil, fmt.Errorf("invalid token issuer")
+	if !c.isIssuerAllowed(claims.Issuer) {
+		return nil, ErrInvalidIssuer
+	}
+
+	// 驗證受眾
+	if !c.isAudienceAllowed(claims.Audience) {
+		return nil, ErrInvalidAudience
 	}
 
+	claims.Permissions = c.limitPermissions(claims.UserID, "jwt", claims.Permissions)
+
 	return claims, nil
 }
 
+// resolveKey 取得驗證 token 所用的公鑰
+func (c *Client) resolveKey(token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+
+	// 多發行者設定：依（尚未驗證的）iss 選擇該發行者的金鑰與演算法，偽造 iss 的 token 無法通過其簽名驗證
+	if len(c.issuers) > 0 {
+		issuer, _ := token.Claims.GetIssuer()
+		if keys, ok := c.issuers[issuer]; ok {
+			if !slices.Contains(keys.algorithms, alg) {
+				return nil, fmt.Errorf("signing algorithm %s is not allowed for issuer %q", alg, issuer)
+			}
+			return selectKey(token, keys.publicKey, keys.jwks, keys.keyID)
+		}
+		if !c.defaultKey {
+			return nil, ErrInvalidIssuer
+		}
+	}
+
+	if !slices.Contains(c.algorithms, alg) {
+		return nil, fmt.Errorf("signing algorithm %s is not allowed", alg)
+	}
+	return selectKey(token, c.publicKey, c.jwks, c.config.KeyID)
+}
+
+// signingAlgorithms 回傳允許的簽名演算法，未設定時使用 RSA 預設值
+func signingAlgorithms(configured []string) ([]string, error) {
+	if len(configured) == 0 {
+		return []string{"RS256", "RS384", "RS512"}, nil
+	}
+
+	algorithms := make([]string, 0, len(configured))
+	for _, alg := range configured {
+		if isForbiddenAlgorithm(alg) {
+			return nil, fmt.Errorf("signing algorithm %q is not allowed", alg)
+		}
+		if jwt.GetSigningMethod(alg) == nil {
+			return nil, fmt.Errorf("unknown signing algorithm %q", alg)
+		}
+		algorithms = append(algorithms, alg)
+	}
+	return algorithms, nil
+}
+
+// isForbiddenAlgorithm 判斷是否為不安全的演算法（none 或 HMAC）
+func isForbiddenAlgorithm(alg string) bool {
+	return strings.EqualFold(alg, "none") || strings.HasPrefix(strings.ToUpper(alg), "HS")
+}
+
+// matchesKeyType 判斷簽名方法是否與公鑰類型相符
+func matchesKeyType(method jwt.SigningMethod, publicKey interface{}) bool {
+	if isForbiddenAlgorithm(method.Alg()) {
+		return false
+	}
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+// limitPermissions 依 MaxPermissions 截斷過大的權限集合
+func (c *Client) limitPermissions(userID, source string, permissions []string) []string {
+	limit := c.config.MaxPermissions
+	if limit <= 0 || len(permissions) <= limit {
+		return permissions
+	}
+
+	c.logger.Warn("Permission set exceeds limit, truncating",
+		zap.String("user_id", userID),
+		zap.String("source", source),
+		zap.Int("count", len(permissions)),
+		zap.Int("max_permissions", limit))
+
+	return permissions[:limit]
+}
+
+// isIssuerAllowed 檢查發行者是否等於 Issuer 或符合 IssuerPattern
+func (c *Client) isIssuerAllowed(issuer string) bool {
+	if _, ok := c.issuers[issuer]; ok {
+		return true
+	}
+	if !c.defaultKey {
+		return false
+	}
+	if c.issuerPattern == nil {
+		return issuer == c.config.Issuer
+	}
+	if c.config.Issuer != "" && issuer == c.config.Issuer {
+		return true
+	}
+	return c.issuerPattern.MatchString(issuer)
+}
+
+// isAudienceAllowed 檢查 aud 聲明與預期的受眾是否有交集
+func (c *Client) isAudienceAllowed(audience jwt.ClaimStrings) bool {
+	if len(c.config.ExpectedAudience) == 0 {
+		return true
+	}
+	for _, aud := range audience {
+		for _, expected := range c.config.ExpectedAudience {
+			if aud == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stripTokenScheme 解析驗證方案前綴
+// 含空白時前段視為 scheme 並須在允許清單內，否則整段視為原始 token
+func (c *Client) stripTokenScheme(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	scheme, token, found := strings.Cut(raw, " ")
+	if !found {
+		return raw, nil
+	}
+
+	allowed := c.config.AllowedTokenSchemes
+	if len(allowed) == 0 {
+		allowed = []string{"Bearer"}
+	}
+
+	for _, allowedScheme := range allowed {
+		if strings.EqualFold(scheme, allowedScheme) {
+			return strings.TrimSpace(token), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported token scheme: %s", scheme)
+}
+
+// matchesTokenTyp 比對 typ 標頭（不分大小寫，並允許省略 application/ 前綴，見 RFC 7515 §4.1.9）
+func matchesTokenTyp(headerTyp interface{}, expected string) bool {
+	typ, ok := headerTyp.(string)
+	if !ok {
+		return false
+	}
+	normalize := func(s string) string {
+		return strings.TrimPrefix(strings.ToLower(s), "application/")
+	}
+	return normalize(typ) == normalize(expected)
+}
+
 // ValidateTokenWithDynamicAuth 驗證 Token 並執行動態權限檢查
-func (c *Client) ValidateTokenWithDynamicAuth(ctx context.Context, tokenString string) (*AuthResult, error) {
+// 啟用 ValidationCacheTTL 時，成功結果依 token 快取於記憶體
+func (c *Client) ValidateTokenWithDynamicAuth(ctx context.Context, tokenString string) (result *AuthResult, err error) {
+	ctx, span := c.tracer.Start(ctx, "auth.ValidateTokenWithDynamicAuth")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(
+				attrUserID.String(result.Claims.UserID),
+				attrIsActive.Bool(result.IsActive),
+				attrForceLogout.Bool(result.ShouldForceLogout),
+				attrDegraded.Bool(result.degraded),
+			)
+		}
+		span.End()
+	}()
+
+	if c.validations == nil && c.rejections == nil {
+		return c.validateWithDynamicAuth(ctx, tokenString)
+	}
+
+	key := tokenCacheKey(tokenString)
+	if key != "" && c.rejections != nil {
+		if cachedErr, ok := c.rejections.Get(key); ok {
+			span.SetAttributes(attrNegativeCacheHit.Bool(true))
+			return nil, cachedErr
+		}
+	}
+	if key != "" && c.validations != nil {
+		if cached, ok := c.validations.Get(key); ok {
+			// 快取期間仍檢查強制登出標記（單次讀取），其他服務設置的強制登出可立即生效
+			forced, err := c.CheckForceLogout(ctx, cached.Claims.UserID, issuedAtUnix(cached.Claims))
+			if err == nil && !forced {
+				span.SetAttributes(attrCacheHit.Bool(true))
+				result := cloneAuthResult(cached)
+				result.ValidatedFromCache = true
+				return result, nil
+			}
+			if forced {
+				c.invalidateValidations(ctx, cached.Claims.UserID)
+			}
+			// 已強制登出或檢查失敗時重新完整驗證
+		}
+	}
+	span.SetAttributes(attrCacheHit.Bool(false))
+
+	result, err = c.validateWithDynamicAuth(ctx, tokenString)
+	if err != nil {
+		if key != "" && c.rejections != nil && isPermanentTokenError(err) {
+			c.rejections.Set(key, err, time.Now().Add(c.config.NegativeCacheTTL))
+		}
+		return nil, err
+	}
+	if c.validations == nil {
+		return result, nil
+	}
+
+	switch {
+	case !result.IsActive || result.ShouldForceLogout:
+		// 用戶已停用或被強制登出：清除該用戶其他 token 的快取結果
+		c.invalidateValidations(ctx, result.Claims.UserID)
+	case key != "" && !result.degraded:
+		expiresAt := time.Now().Add(c.config.ValidationCacheTTL)
+		if exp := result.Claims.ExpiresAt; exp != nil && exp.Time.Before(expiresAt) {
+			expiresAt = exp.Time
+		}
+		c.validations.Set(key, cloneAuthResult(result), expiresAt)
+	}
+
+	return result, nil
+}
+
+// ValidationCacheStats 回傳驗證快取的命中統計（未啟用時為零值）
+func (c *Client) ValidationCacheStats() CacheStats {
+	if c.validations == nil {
+		return CacheStats{}
+	}
+	return c.validations.Stats()
+}
+
+// NegativeCacheStats 回傳無效 token 快取的命中統計（未啟用時為零值）
+func (c *Client) NegativeCacheStats() CacheStats {
+	if c.rejections == nil {
+		return CacheStats{}
+	}
+	return c.rejections.Stats()
+}
+
+// StoreStats 回傳降級鏈各層的使用統計（未設定 FallbackStores 時回傳 nil）
+func (c *Client) StoreStats() map[string]TierStats {
+	if fallback, ok := c.store.(*FallbackStore); ok {
+		return fallback.Stats()
+	}
+	return nil
+}
+
+// invalidateValidations 清除指定用戶的所有快取驗證結果
+func (c *Client) invalidateValidations(ctx context.Context, userID string) {
+	if c.validations == nil {
+		return
+	}
+	removed := c.validations.DeleteFunc(func(_ string, result *AuthResult) bool {
+		return result.Claims.UserID == userID
+	})
+	if removed > 0 {
+		c.requestLogger(ctx).Debug("Invalidated cached validations",
+			zap.String("user_id", userID), zap.Int("count", removed))
+	}
+}
+
+// cloneAuthResult 深拷貝驗證結果，避免呼叫端修改回傳的切片時影響快取內容
+func cloneAuthResult(result *AuthResult) *AuthResult {
+	cloned := *result
+	cloned.DynamicPermissions = slices.Clone(result.DynamicPermissions)
+	if result.Claims != nil {
+		claims := *result.Claims
+		claims.Roles = slices.Clone(claims.Roles)
+		claims.Permissions = slices.Clone(claims.Permissions)
+		claims.Audience = slices.Clone(claims.Audience)
+		claims.ExpiresAt = cloneNumericDate(claims.ExpiresAt)
+		claims.NotBefore = cloneNumericDate(claims.NotBefore)
+		claims.IssuedAt = cloneNumericDate(claims.IssuedAt)
+		cloned.Claims = &claims
+	}
+	return &cloned
+}
+
+// cloneNumericDate 複製 JWT 時間欄位
+func cloneNumericDate(date *jwt.NumericDate) *jwt.NumericDate {
+	if date == nil {
+		return nil
+	}
+	cloned := *date
+	return &cloned
+}
+
+// issuedAtUnix 回傳 token 的簽發時間（Unix 秒），未帶 iat 時為 0
+func issuedAtUnix(claims *Claims) int64 {
+	if claims.IssuedAt == nil {
+		return 0
+	}
+	return claims.IssuedAt.Unix()
+}
+
+// requestLogger 回傳帶有 context 中請求 ID 的 logger，讓驗證日誌能對應到原始請求
+func (c *Client) requestLogger(ctx context.Context) *zap.Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return c.logger.With(zap.String("request_id", requestID))
+	}
+	return c.logger
+}
+
+// tokenCacheKey 以完整 token（含簽名）的 SHA-256 作為快取鍵，格式不符時回傳空字串
+// 僅以簽名區段為鍵時，竄改 header 或 payload 但保留簽名的 token 會命中原 token 的結果
+func tokenCacheKey(tokenString string) string {
+	tokenString = strings.TrimSpace(tokenString)
+	if _, token, found := strings.Cut(tokenString, " "); found {
+		tokenString = strings.TrimSpace(token)
+	}
+	if strings.Count(tokenString, ".") != 2 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateWithDynamicAuth 執行完整的 JWT 驗證與動態檢查，不經過驗證快取
+func (c *Client) validateWithDynamicAuth(ctx context.Context, tokenString string) (*AuthResult, error) {
+	// 整體時間預算：JWT 解析與所有 Redis 查詢共用同一個 deadline
+	if c.config.DynamicAuthTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.DynamicAuthTimeout)
+		defer cancel()
+	}
+
+	logger := c.requestLogger(ctx)
+
 	// 1. 驗證 JWT Token
 	claims, err := c.ValidateToken(tokenString)
 	if err != nil {
@@ -158,12 +959,29 @@ func (c *Client) ValidateTokenWithDynamicAuth(ctx context.Context, tokenString s
 		Claims: claims,
 	}
 
+	// JWT 解析無法中斷，完成後若已超出時間預算則直接採用容錯預設值
+	if err := ctx.Err(); err != nil {
+		if c.config.FailClosed {
+			return nil, fmt.Errorf("%w: %w", ErrDynamicAuthUnavailable, err)
+		}
+		logger.Warn("Dynamic auth budget exhausted, using fault-tolerant defaults",
+			zap.String("user_id", claims.UserID), zap.Error(err))
+		result.IsActive = true
+		result.DynamicPermissions = claims.Permissions
+		result.degraded = true
+		return result, nil
+	}
+
 	// 2. 檢查用戶狀態
 	isActive, err := c.CheckUserStatus(ctx, claims.UserID)
 	if err != nil {
-		c.logger.Warn("Failed to check user status, defaulting to active", 
+		if c.config.FailClosed {
+			return nil, fmt.Errorf("%w: user status: %w", ErrDynamicAuthUnavailable, err)
+		}
+		logger.Warn("Failed to check user status, defaulting to active",
 			zap.String("user_id", claims.UserID), zap.Error(err))
 		isActive = true // 容錯：預設為啟用
+		result.degraded = true
 	}
 	result.IsActive = isActive
 
@@ -172,144 +990,283 @@ func (c *Client) ValidateTokenWithDynamicAuth(ctx context.Context, tokenString s
 	}
 
 	// 3. 檢查強制登出
-	shouldForceLogout, err := c.CheckForceLogout(ctx, claims.UserID, claims.IssuedAt.Unix())
+	shouldForceLogout, err := c.CheckForceLogout(ctx, claims.UserID, issuedAtUnix(claims))
+	if err != nil && c.config.FailClosed {
+		if !errors.Is(err, ErrMissingIssuedAt) {
+			return nil, fmt.Errorf("%w: force logout: %w", ErrDynamicAuthUnavailable, err)
+		}
+		// 存在強制登出標記但無法證明 token 簽發於其後，要求重新登入
+		shouldForceLogout, err = true, nil
+	}
 	if err != nil {
-		c.logger.Warn("Failed to check force logout, defaulting to false", 
+		logger.Warn("Failed to check force logout, defaulting to false",
 			zap.String("user_id", claims.UserID), zap.Error(err))
 		shouldForceLogout = false // 容錯：預設不強制登出
+		result.degraded = true
 	}
 	result.ShouldForceLogout = shouldForceLogout
 
 	// 4. 獲取動態權限
 	dynamicPermissions, err := c.GetUserDynamicPermissions(ctx, claims.UserID)
 	if err != nil {
-		c.logger.Warn("Failed to get dynamic permissions, using JWT permissions", 
+		logger.Warn("Failed to get dynamic permissions, using JWT permissions",
 			zap.String("user_id", claims.UserID), zap.Error(err))
 		dynamicPermissions = claims.Permissions // 容錯：使用 JWT 中的權限
+		result.degraded = true
 	}
-	result.DynamicPermissions = dynamicPermissions
+	result.DynamicPermissions = c.limitPermissions(claims.UserID, "dynamic", dynamicPermissions)
 
 	return result, nil
 }
 
 // CheckUserStatus 檢查用戶狀態
 func (c *Client) CheckUserStatus(ctx context.Context, userID string) (bool, error) {
-	key := fmt.Sprintf("user:status:%s", userID)
-	
-	val, err := c.redisClient.Get(ctx, key).Result()
+	ctx, span := c.startStoreSpan(ctx, "auth.CheckUserStatus", userID)
+	status, err := c.store.GetUserStatus(ctx, userID)
+	endStoreSpan(span, err)
 	if err != nil {
-		if err == redis.Nil {
-			return true, nil // 緩存不存在，預設為啟用
+		if errors.Is(err, ErrCacheNotFound) {
+			return c.defaultUserActive(), nil // 緩存不存在，依設定決定預設狀態
 		}
-		return true, err // 容錯：查詢失敗時允許通過
+		return true, err // 容錯：查詢或解析失敗時允許通過
 	}
 
-	var status UserStatus
-	if err := json.Unmarshal([]byte(val), &status); err != nil {
-		return true, fmt.Errorf("failed to parse user status: %w", err)
+	return status.IsActive, nil
+}
+
+// CheckUserStatuses 批次檢查多個用戶的狀態（Store 支援時以單次請求完成）
+// 快取不存在的用戶與 CheckUserStatus 相同依設定決定預設狀態；
+// 個別查詢或解析失敗的用戶不會出現在結果中，錯誤合併後連同已取得的結果一併回傳
+func (c *Client) CheckUserStatuses(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	statuses := make(map[string]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return statuses, nil
 	}
 
-	return status.IsActive, nil
+	batch, ok := c.store.(BatchPermissionStore)
+	if !ok {
+		var errs []error
+		for _, userID := range userIDs {
+			isActive, err := c.CheckUserStatus(ctx, userID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", userID, err))
+				continue
+			}
+			statuses[userID] = isActive
+		}
+		return statuses, errors.Join(errs...)
+	}
+
+	found, err := batch.GetUserStatuses(ctx, userIDs)
+	for userID, status := range found {
+		if status == nil {
+			statuses[userID] = c.defaultUserActive()
+			continue
+		}
+		statuses[userID] = status.IsActive
+	}
+
+	return statuses, err
+}
+
+// defaultUserActive 回傳狀態快取不存在時的預設啟用狀態
+func (c *Client) defaultUserActive() bool {
+	if c.config.DefaultUserActive == nil {
+		return true
+	}
+	return *c.config.DefaultUserActive
 }
 
 // CheckForceLogout 檢查強制登出標記
 func (c *Client) CheckForceLogout(ctx context.Context, userID string, tokenIssuedAt int64) (bool, error) {
-	key := fmt.Sprintf("user:force_logout:%s", userID)
-	
-	val, err := c.redisClient.Get(ctx, key).Result()
+	ctx, span := c.startStoreSpan(ctx, "auth.CheckForceLogout", userID)
+	forceLogoutTimestamp, err := c.store.GetForceLogout(ctx, userID)
+	endStoreSpan(span, err)
 	if err != nil {
-		if err == redis.Nil {
+		if errors.Is(err, ErrCacheNotFound) {
 			return false, nil // 沒有強制登出標記
 		}
-		return false, err
+		return false, err // 容錯：查詢或解析失敗時預設不強制登出
 	}
 
-	// 解析時間戳
-	forceLogoutTimestamp, err := strconv.ParseInt(val, 10, 64)
-	if err != nil {
-		return false, fmt.Errorf("failed to parse force logout timestamp: %w", err)
+	// 缺少簽發時間時無從比較，不可直接視為強制登出
+	if tokenIssuedAt <= 0 {
+		if c.config.SkipForceLogoutWithoutIssuedAt {
+			return false, nil
+		}
+		return false, ErrMissingIssuedAt
 	}
 
-	// 如果強制登出時間晚於 token 簽發時間，則需要重新登入
-	return forceLogoutTimestamp > tokenIssuedAt, nil
+	// 如果強制登出時間晚於（預設含同一秒）token 簽發時間，則需要重新登入
+	if c.config.ExclusiveForceLogoutBoundary {
+		return forceLogoutTimestamp > tokenIssuedAt, nil
+	}
+	return forceLogoutTimestamp >= tokenIssuedAt, nil
 }
 
 // GetUserDynamicPermissions 獲取用戶的動態權限
 func (c *Client) GetUserDynamicPermissions(ctx context.Context, userID string) ([]string, error) {
-	key := fmt.Sprintf("user:dynamic_permissions:%s", userID)
-	
-	val, err := c.redisClient.Get(ctx, key).Result()
+	ctx, span := c.startStoreSpan(ctx, "auth.GetUserDynamicPermissions", userID)
+	permissions, err := c.store.GetDynamicPermissions(ctx, userID)
+	endStoreSpan(span, err)
 	if err != nil {
-		if err == redis.Nil {
+		if errors.Is(err, ErrCacheNotFound) {
 			return nil, nil // 緩存不存在
 		}
-		return nil, err
+		return nil, err // 容錯：由呼叫端改用 JWT 權限
 	}
 
-	var cacheData map[string]interface{}
-	if err := json.Unmarshal([]byte(val), &cacheData); err != nil {
-		return nil, fmt.Errorf("failed to parse cached permissions: %w", err)
+	return permissions, nil
+}
+
+// GetUserDynamicPermissionsBatch 批次獲取多個用戶的動態權限（Store 支援時以單次請求完成）
+// 快取不存在或內容無法解析的用戶對應 nil，由呼叫端自行改用 JWT 權限；
+// 查詢失敗的用戶不會出現在結果中，錯誤合併後連同已取得的結果一併回傳
+func (c *Client) GetUserDynamicPermissionsBatch(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	if len(userIDs) == 0 {
+		return make(map[string][]string), nil
 	}
 
-	// 解析權限列表
-	permissionsData, ok := cacheData["permissions"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid permissions format in cache")
+	if batch, ok := c.store.(BatchPermissionStore); ok {
+		return batch.GetDynamicPermissionsBatch(ctx, userIDs)
 	}
 
-	permissions := make([]string, len(permissionsData))
-	for i, perm := range permissionsData {
-		if permStr, ok := perm.(string); ok {
-			permissions[i] = permStr
+	result := make(map[string][]string, len(userIDs))
+	var errs []error
+	for _, userID := range userIDs {
+		permissions, err := c.store.GetDynamicPermissions(ctx, userID)
+		switch {
+		case err == nil:
+			result[userID] = permissions
+		case errors.Is(err, ErrCacheNotFound), errors.Is(err, ErrCorruptCache):
+			result[userID] = nil
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", userID, err))
 		}
 	}
+	return result, errors.Join(errs...)
+}
 
-	return permissions, nil
+// GetPermissionCacheAge 估算動態權限快取的陳舊程度（現在時間減去寫入時間）
+// 需要寫入端在快取中帶上 updated_at，供監控任務偵測寫入端停止更新的情況
+func (c *Client) GetPermissionCacheAge(ctx context.Context, userID string) (time.Duration, error) {
+	ageStore, ok := c.store.(PermissionAgeStore)
+	if !ok {
+		return 0, ErrCacheTimestampMissing
+	}
+
+	updatedAt, err := ageStore.GetDynamicPermissionsUpdatedAt(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Since(updatedAt), nil
 }
 
-// SetUserStatus 設置用戶狀態
+// SetUserStatus 設置用戶狀態（以目前時間作為 UpdatedAt）
 func (c *Client) SetUserStatus(ctx context.Context, userID string, isActive bool) error {
-	key := fmt.Sprintf("user:status:%s", userID)
+	return c.SetUserStatusAt(ctx, userID, isActive, time.Now())
+}
+
+// SetUserStatusAt 以指定的變更時間設置用戶狀態，供同步來源資料的程序保留原始變更時間
+// 已存在較新（或相同時間）的狀態時不會覆蓋，回傳包裝 ErrStaleUserStatus 的錯誤
+func (c *Client) SetUserStatusAt(ctx context.Context, userID string, isActive bool, updatedAt time.Time) error {
 	status := UserStatus{
 		IsActive:  isActive,
-		UpdatedAt: time.Now(),
+		UpdatedAt: updatedAt,
 	}
 
-	data, err := json.Marshal(status)
-	if err != nil {
-		return fmt.Errorf("failed to marshal user status: %w", err)
-	}
-
-	err = c.redisClient.Set(ctx, key, string(data), 10*time.Minute).Err()
-	if err != nil {
+	if err := c.store.SetUserStatus(ctx, userID, status); err != nil {
 		return fmt.Errorf("failed to set user status: %w", err)
 	}
 
+	c.invalidateValidations(ctx, userID)
+
 	return nil
 }
 
 // SetForceLogout 設置強制登出標記
 func (c *Client) SetForceLogout(ctx context.Context, userID string) error {
-	key := fmt.Sprintf("user:force_logout:%s", userID)
-	timestamp := time.Now().Unix()
-
-	err := c.redisClient.Set(ctx, key, timestamp, 24*time.Hour).Err()
-	if err != nil {
+	if err := c.store.SetForceLogout(ctx, userID, time.Now().Unix()); err != nil {
 		return fmt.Errorf("failed to set force logout: %w", err)
 	}
 
+	c.invalidateValidations(ctx, userID)
+
 	return nil
 }
 
-// Close 關閉客戶端連接
-func (c *Client) Close() error {
-	if c.redisClient != nil {
-		return c.redisClient.Close()
+// ClearForceLogout 清除強制登出標記（管理功能）
+func (c *Client) ClearForceLogout(ctx context.Context, userID string) error {
+	if err := c.store.ClearForceLogout(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear force logout: %w", err)
 	}
+
 	return nil
 }
 
+// RefreshToken 透過 Auth 服務以 refresh token 換發新的 Token 組
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if c.config.AuthServiceURL == "" {
+		return nil, errors.New("auth service URL is not configured")
+	}
+	if refreshToken == "" {
+		return nil, errors.New("missing refresh token")
+	}
+
+	body, err := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refresh request: %w", err)
+	}
+
+	url := strings.TrimRight(c.config.AuthServiceURL, "/") + "/api/v1/auth/refresh"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call refresh endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("refresh endpoint returned status %d", resp.StatusCode)
+	}
+
+	// 兼容統一回應格式 {"success": true, "data": {...}} 與直接回傳 Token 組
+	var envelope struct {
+		Data *TokenPair `json:"data"`
+		TokenPair
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh response: %w", err)
+	}
+
+	pair := &envelope.TokenPair
+	if envelope.Data != nil {
+		pair = envelope.Data
+	}
+	if pair.AccessToken == "" {
+		return nil, errors.New("refresh response missing access token")
+	}
+
+	return pair, nil
+}
+
+// Close 關閉客戶端連接（會先清空緩衝資料，詳見 Shutdown）
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	_, err := c.Shutdown(ctx)
+	return err
+}
+
 // loadPublicKey 載入 RSA 公鑰
+// 支援 PKIX（PUBLIC KEY）與 PKCS#1（RSA PUBLIC KEY）兩種 PEM 格式
 func loadPublicKey(path string) (interface{}, error) {
 	// 讀取公鑰檔案
 	keyData, err := os.ReadFile(path)
@@ -324,16 +1281,24 @@ func loadPublicKey(path string) (interface{}, error) {
 	}
 
 	// 解析公鑰
-	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	var publicKey interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse public key: %w", err)
 	}
 
-	// 確認是 RSA 公鑰
-	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("not an RSA public key")
+	// 確認是 RSA、ECDSA 或 Ed25519 公鑰
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
 	}
-
-	return rsaPublicKey, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,808 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const testIssuer = "auth-test"
+
+// writePublicKeyPEM 將公鑰以指定的 PEM 區塊類型寫入暫存目錄並回傳路徑
+func writePublicKeyPEM(t testing.TB, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "public_key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	return path
+}
+
+// newRSAKey 產生測試用 RSA 金鑰並回傳私鑰與 PKIX 公鑰檔路徑
+func newRSAKey(t testing.TB) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return key, writePublicKeyPEM(t, "PUBLIC KEY", der)
+}
+
+// newTestClient 以 miniredis 作為 Redis 建立客戶端，config 未設定的欄位使用測試預設值
+func newTestClient(t testing.TB, config *Config) *Client {
+	t.Helper()
+	if config.RedisClient == nil && config.Store == nil {
+		_, redisClient := newTestRedis(t)
+		config.RedisClient = redisClient
+	}
+	if config.Issuer == "" && config.IssuerPattern == "" {
+		config.Issuer = testIssuer
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// testClaims 回傳一小時後過期、由 testIssuer 簽發的 access token 聲明
+func testClaims(userID string) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:    userID,
+		TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+// signTestToken 以指定的方法與私鑰簽發 token，header 中的值會覆寫預設標頭
+func signTestToken(t testing.TB, method jwt.SigningMethod, key interface{}, claims *Claims, header map[string]interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	for name, value := range header {
+		if value == nil {
+			delete(token.Header, name)
+			continue
+		}
+		token.Header[name] = value
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestValidateTokenTypHeader(t *testing.T) {
+	key, publicKeyPath := newRSAKey(t)
+
+	tests := []struct {
+		name     string
+		expected string
+		typ      interface{} // nil 表示移除 typ 標頭
+		wantErr  bool
+	}{
+		{name: "not checked when unset", expected: "", typ: "anything"},
+		{name: "exact match", expected: "JWT", typ: "JWT"},
+		{name: "case insensitive", expected: "at+jwt", typ: "AT+JWT"},
+		{name: "application prefix in header", expected: "at+jwt", typ: "application/at+jwt"},
+		{name: "application prefix in config", expected: "application/at+jwt", typ: "at+jwt"},
+		{name: "mismatch", expected: "at+jwt", typ: "JWT", wantErr: true},
+		{name: "missing header", expected: "JWT", typ: nil, wantErr: true},
+		{name: "non-string header", expected: "JWT", typ: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{PublicKeyPath: publicKeyPath, ExpectedTokenTyp: tt.expected})
+			token := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("42"), map[string]interface{}{"typ": tt.typ})
+
+			_, err := client.ValidateToken(token)
+			if tt.wantErr && err == nil {
+				t.Fatal("ValidateToken accepted a token with the wrong typ")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+		})
+	}
+}
+
+func TestStripTokenScheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "raw token", raw: "abc.def.ghi", want: "abc.def.ghi"},
+		{name: "bearer", raw: "Bearer abc.def.ghi", want: "abc.def.ghi"},
+		{name: "bearer case insensitive", raw: "bearer abc.def.ghi", want: "abc.def.ghi"},
+		{name: "surrounding whitespace", raw: "  Bearer   abc.def.ghi  ", want: "abc.def.ghi"},
+		{name: "unsupported scheme by default", raw: "Token abc.def.ghi", wantErr: true},
+		{name: "basic rejected", raw: "Basic dXNlcjpwYXNz", wantErr: true},
+		{name: "custom scheme allowed", allowed: []string{"Bearer", "Token"}, raw: "Token abc.def.ghi", want: "abc.def.ghi"},
+		{name: "bearer not implied by custom list", allowed: []string{"Token"}, raw: "Bearer abc.def.ghi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{config: &Config{AllowedTokenSchemes: tt.allowed}}
+			got, err := c.stripTokenScheme(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("stripTokenScheme(%q) = %q, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stripTokenScheme(%q) error = %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("stripTokenScheme(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenWithScheme(t *testing.T) {
+	key, publicKeyPath := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: publicKeyPath, AllowedTokenSchemes: []string{"Bearer", "JWT"}})
+	token := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("42"), nil)
+
+	for _, raw := range []string{token, "Bearer " + token, "JWT " + token} {
+		if _, err := client.ValidateToken(raw); err != nil {
+			t.Errorf("ValidateToken(%.10q...) error = %v", raw, err)
+		}
+	}
+	if _, err := client.ValidateToken("Basic " + token); err == nil {
+		t.Error("ValidateToken accepted an unsupported scheme")
+	}
+}
+
+func TestIssuerPatternPerEnvironment(t *testing.T) {
+	key, publicKeyPath := newRSAKey(t)
+
+	tests := []struct {
+		name    string
+		issuer  string
+		pattern string
+		iss     string
+		wantErr bool
+	}{
+		{name: "exact issuer", issuer: "auth.prod", iss: "auth.prod"},
+		{name: "exact issuer rejects staging", issuer: "auth.prod", iss: "auth.staging", wantErr: true},
+		{name: "pattern accepts prod", pattern: `auth\.prod(\..+)?`, iss: "auth.prod"},
+		{name: "pattern accepts prod region", pattern: `auth\.prod(\..+)?`, iss: "auth.prod.eu-west-1"},
+		{name: "pattern rejects staging", pattern: `auth\.prod(\..+)?`, iss: "auth.staging", wantErr: true},
+		{name: "pattern rejects staging region", pattern: `auth\.prod(\..+)?`, iss: "auth.staging.eu-west-1", wantErr: true},
+		{name: "pattern is anchored at start", pattern: `auth\.prod(\..+)?`, iss: "evil.auth.prod", wantErr: true},
+		{name: "pattern is anchored at end", pattern: `auth\.prod`, iss: "auth.prod.evil", wantErr: true},
+		{name: "alternation stays anchored", pattern: `auth\.prod|auth\.dr`, iss: "auth.dr.evil", wantErr: true},
+		{name: "issuer or pattern", issuer: "auth.legacy", pattern: `auth\.prod`, iss: "auth.legacy"},
+		{name: "empty issuer rejected by pattern", pattern: `auth\.prod(\..+)?`, iss: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{PublicKeyPath: publicKeyPath, Issuer: tt.issuer, IssuerPattern: tt.pattern})
+			claims := testClaims("42")
+			claims.Issuer = tt.iss
+
+			_, err := client.ValidateToken(signTestToken(t, jwt.SigningMethodRS256, key, claims, nil))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIssuer) {
+					t.Fatalf("ValidateToken err = %v, want ErrInvalidIssuer", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+		})
+	}
+}
+
+func TestIssuerPatternInvalid(t *testing.T) {
+	_, publicKeyPath := newRSAKey(t)
+	_, err := NewClient(&Config{PublicKeyPath: publicKeyPath, IssuerPattern: "auth.(prod", Logger: zap.NewNop()})
+	if err == nil {
+		t.Fatal("NewClient accepted an invalid issuer pattern")
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate EC key: %v", err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+
+	pkix := func(key interface{}) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+		return der
+	}
+	rsaPrivateDER := x509.MarshalPKCS1PrivateKey(rsaKey)
+
+	tests := []struct {
+		name      string
+		blockType string
+		der       []byte
+		wantType  string
+		wantErr   bool
+	}{
+		{name: "PKIX RSA", blockType: "PUBLIC KEY", der: pkix(&rsaKey.PublicKey), wantType: "*rsa.PublicKey"},
+		{name: "PKCS1 RSA", blockType: "RSA PUBLIC KEY", der: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), wantType: "*rsa.PublicKey"},
+		{name: "PKIX ECDSA", blockType: "PUBLIC KEY", der: pkix(&ecKey.PublicKey), wantType: "*ecdsa.PublicKey"},
+		{name: "PKIX Ed25519", blockType: "PUBLIC KEY", der: pkix(edPublic), wantType: "ed25519.PublicKey"},
+		{name: "PKCS1 block with PKIX body", blockType: "RSA PUBLIC KEY", der: pkix(&rsaKey.PublicKey), wantErr: true},
+		{name: "PKIX block with PKCS1 body", blockType: "PUBLIC KEY", der: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), wantErr: true},
+		{name: "private key block", blockType: "RSA PRIVATE KEY", der: rsaPrivateDER, wantErr: true},
+		{name: "certificate block", blockType: "CERTIFICATE", der: []byte("not a certificate"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := loadPublicKey(writePublicKeyPEM(t, tt.blockType, tt.der))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadPublicKey accepted %s", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadPublicKey: %v", err)
+			}
+			if got := fmt.Sprintf("%T", key); got != tt.wantType {
+				t.Errorf("key type = %s, want %s", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestLoadPublicKeyInvalidFile(t *testing.T) {
+	if _, err := loadPublicKey(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("loadPublicKey accepted a missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(path, []byte("not pem"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPublicKey(path); err == nil {
+		t.Error("loadPublicKey accepted a file without a PEM block")
+	}
+}
+
+// newEd25519Key 產生測試用 Ed25519 金鑰並回傳私鑰與 PKIX 公鑰檔路徑
+func newEd25519Key(t *testing.T) (ed25519.PrivateKey, string) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return private, writePublicKeyPEM(t, "PUBLIC KEY", der)
+}
+
+func TestValidateTokenEdDSA(t *testing.T) {
+	edKey, edPublicPath := newEd25519Key(t)
+	otherEdKey, _ := newEd25519Key(t)
+	rsaKey, _ := newRSAKey(t)
+
+	tests := []struct {
+		name       string
+		algorithms []string
+		method     jwt.SigningMethod
+		key        interface{}
+		wantErr    bool
+	}{
+		{name: "allowlisted EdDSA", algorithms: []string{"EdDSA"}, method: jwt.SigningMethodEdDSA, key: edKey},
+		{name: "EdDSA not allowlisted by default", method: jwt.SigningMethodEdDSA, key: edKey, wantErr: true},
+		{name: "wrong Ed25519 key", algorithms: []string{"EdDSA"}, method: jwt.SigningMethodEdDSA, key: otherEdKey, wantErr: true},
+		{name: "RSA token against Ed25519 key", algorithms: []string{"EdDSA", "RS256"}, method: jwt.SigningMethodRS256, key: rsaKey, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{PublicKeyPath: edPublicPath, SigningAlgorithms: tt.algorithms})
+			token := signTestToken(t, tt.method, tt.key, testClaims("42"), nil)
+
+			claims, err := client.ValidateToken(token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ValidateToken accepted the token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if claims.UserID != "42" {
+				t.Errorf("UserID = %q", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestSigningAlgorithmsRejectsUnsafe(t *testing.T) {
+	for _, alg := range []string{"none", "HS256", "hs512", "XX999"} {
+		if _, err := signingAlgorithms([]string{alg}); err == nil {
+			t.Errorf("signingAlgorithms accepted %q", alg)
+		}
+	}
+}
+
+func TestCheckForceLogoutWithoutIssuedAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		marker   bool
+		skip     bool
+		issuedAt int64
+		want     bool
+		wantErr  error
+	}{
+		{name: "no marker", issuedAt: 0, want: false},
+		{name: "marker and zero iat", marker: true, issuedAt: 0, wantErr: ErrMissingIssuedAt},
+		{name: "marker and negative iat", marker: true, issuedAt: -1, wantErr: ErrMissingIssuedAt},
+		{name: "marker and zero iat skipped", marker: true, skip: true, issuedAt: 0, want: false},
+		{name: "marker and iat after", marker: true, issuedAt: 2000, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, redisClient := newTestRedis(t)
+			if tt.marker {
+				server.Set("user:force_logout:42", "1000")
+			}
+			client := newTestClient(t, &Config{
+				PublicKeyPath:                  mustRSAKeyPath(t),
+				RedisClient:                    redisClient,
+				SkipForceLogoutWithoutIssuedAt: tt.skip,
+			})
+
+			got, err := client.CheckForceLogout(context.Background(), "42", tt.issuedAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckForceLogout err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CheckForceLogout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckForceLogoutBoundary(t *testing.T) {
+	tests := []struct {
+		name      string
+		exclusive bool
+		issuedAt  int64
+		want      bool
+	}{
+		{name: "iat before marker", issuedAt: 999, want: true},
+		{name: "iat equals marker", issuedAt: 1000, want: true},
+		{name: "iat after marker", issuedAt: 1001, want: false},
+		{name: "exclusive iat before marker", exclusive: true, issuedAt: 999, want: true},
+		{name: "exclusive iat equals marker", exclusive: true, issuedAt: 1000, want: false},
+		{name: "exclusive iat after marker", exclusive: true, issuedAt: 1001, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, redisClient := newTestRedis(t)
+			server.Set("user:force_logout:42", "1000")
+			client := newTestClient(t, &Config{
+				PublicKeyPath:                mustRSAKeyPath(t),
+				RedisClient:                  redisClient,
+				ExclusiveForceLogoutBoundary: tt.exclusive,
+			})
+
+			got, err := client.CheckForceLogout(context.Background(), "42", tt.issuedAt)
+			if err != nil {
+				t.Fatalf("CheckForceLogout: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckForceLogout(iat=%d) = %v, want %v", tt.issuedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenWithDynamicAuthWithoutIssuedAt(t *testing.T) {
+	key, publicKeyPath := newRSAKey(t)
+	claims := testClaims("42")
+	claims.IssuedAt = nil
+
+	for _, failClosed := range []bool{false, true} {
+		server, redisClient := newTestRedis(t)
+		server.Set("user:force_logout:42", "1000")
+		client := newTestClient(t, &Config{PublicKeyPath: publicKeyPath, RedisClient: redisClient, FailClosed: failClosed})
+
+		result, err := client.ValidateTokenWithDynamicAuth(context.Background(), signTestToken(t, jwt.SigningMethodRS256, key, claims, nil))
+		if err != nil {
+			t.Fatalf("FailClosed=%v: ValidateTokenWithDynamicAuth: %v", failClosed, err)
+		}
+		// 容錯模式放行，FailClosed 要求重新登入
+		if result.ShouldForceLogout != failClosed {
+			t.Errorf("FailClosed=%v: ShouldForceLogout = %v", failClosed, result.ShouldForceLogout)
+		}
+	}
+}
+
+func TestCheckUserStatusDefaultUserActive(t *testing.T) {
+	inactive, active := false, true
+
+	tests := []struct {
+		name          string
+		status        string // 空字串表示狀態快取不存在
+		defaultActive *bool
+		want          bool
+	}{
+		{name: "missing key defaults to active", want: true},
+		{name: "missing key with explicit true", defaultActive: &active, want: true},
+		{name: "missing key with false", defaultActive: &inactive, want: false},
+		{name: "stored status wins over default", status: `{"is_active": true}`, defaultActive: &inactive, want: true},
+		{name: "stored inactive status", status: `{"is_active": false}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, redisClient := newTestRedis(t)
+			if tt.status != "" {
+				server.Set("user:status:42", tt.status)
+			}
+			client := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t), RedisClient: redisClient, DefaultUserActive: tt.defaultActive})
+
+			got, err := client.CheckUserStatus(context.Background(), "42")
+			if err != nil {
+				t.Fatalf("CheckUserStatus: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckUserStatus = %v, want %v", got, tt.want)
+			}
+
+			statuses, err := client.CheckUserStatuses(context.Background(), []string{"42"})
+			if err != nil {
+				t.Fatalf("CheckUserStatuses: %v", err)
+			}
+			if statuses["42"] != tt.want {
+				t.Errorf("CheckUserStatuses[42] = %v, want %v", statuses["42"], tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenClockSkew(t *testing.T) {
+	key, path := newRSAKey(t)
+
+	tests := []struct {
+		name      string
+		skew      time.Duration
+		expiresIn time.Duration
+		notBefore time.Duration
+		wantErr   error
+	}{
+		{name: "expired without leeway", skew: 0, expiresIn: -10 * time.Second, wantErr: ErrTokenExpired},
+		{name: "expired within leeway", skew: 30 * time.Second, expiresIn: -10 * time.Second},
+		{name: "expired beyond leeway", skew: 30 * time.Second, expiresIn: -40 * time.Second, wantErr: ErrTokenExpired},
+		{name: "not yet valid without leeway", skew: 0, expiresIn: time.Hour, notBefore: 10 * time.Second, wantErr: ErrTokenNotValidYet},
+		{name: "not yet valid within leeway", skew: 30 * time.Second, expiresIn: time.Hour, notBefore: 10 * time.Second},
+		{name: "not yet valid beyond leeway", skew: 30 * time.Second, expiresIn: time.Hour, notBefore: 40 * time.Second, wantErr: ErrTokenNotValidYet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{PublicKeyPath: path, ClockSkew: tt.skew})
+
+			now := time.Now()
+			claims := testClaims("42")
+			claims.IssuedAt = jwt.NewNumericDate(now.Add(-time.Hour))
+			claims.ExpiresAt = jwt.NewNumericDate(now.Add(tt.expiresIn))
+			if tt.notBefore != 0 {
+				claims.NotBefore = jwt.NewNumericDate(now.Add(tt.notBefore))
+			}
+			token := signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+
+			_, err := client.ValidateToken(token)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateToken err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTokenType(t *testing.T) {
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: path})
+	var _ TokenTypeValidator = client
+
+	tokenOfType := func(tokenType string) string {
+		claims := testClaims("42")
+		claims.TokenType = tokenType
+		return signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+	}
+
+	tests := []struct {
+		name      string
+		tokenType string
+		validate  func(string) (*Claims, error)
+		wantErr   error
+	}{
+		{name: "access as access", tokenType: TokenTypeAccess, validate: client.ValidateAccessToken},
+		{name: "legacy without type as access", tokenType: "", validate: client.ValidateAccessToken},
+		{name: "refresh as access", tokenType: TokenTypeRefresh, validate: client.ValidateAccessToken, wantErr: ErrWrongTokenType},
+		{name: "refresh as ValidateToken", tokenType: TokenTypeRefresh, validate: client.ValidateToken, wantErr: ErrWrongTokenType},
+		{name: "refresh as refresh", tokenType: TokenTypeRefresh, validate: client.ValidateRefreshToken},
+		{name: "access as refresh", tokenType: TokenTypeAccess, validate: client.ValidateRefreshToken, wantErr: ErrWrongTokenType},
+		{name: "legacy without type as refresh", tokenType: "", validate: client.ValidateRefreshToken, wantErr: ErrWrongTokenType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.validate(tokenOfType(tt.tokenType))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+			if claims.UserID != "42" {
+				t.Errorf("UserID = %q, want 42", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestValidationCacheReturnsCopies(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:dynamic_permissions:42", `["user:read"]`)
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{RedisClient: redisClient, PublicKeyPath: path, ValidationCacheTTL: time.Minute})
+
+	claims := testClaims("42")
+	claims.Roles = []string{"viewer"}
+	token := signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+	ctx := context.Background()
+
+	first, err := client.ValidateTokenWithDynamicAuth(ctx, token)
+	if err != nil {
+		t.Fatalf("first validation: %v", err)
+	}
+	first.DynamicPermissions[0] = "admin:*"
+	first.Claims.Roles[0] = "admin"
+	first.Claims.UserID = "1"
+
+	for i := 0; i < 2; i++ {
+		cached, err := client.ValidateTokenWithDynamicAuth(ctx, token)
+		if err != nil {
+			t.Fatalf("cached validation: %v", err)
+		}
+		if !cached.ValidatedFromCache {
+			t.Fatal("result not served from cache")
+		}
+		if cached.DynamicPermissions[0] != "user:read" || cached.Claims.Roles[0] != "viewer" || cached.Claims.UserID != "42" {
+			t.Fatalf("cached result mutated through a returned copy: %+v %+v", cached, cached.Claims)
+		}
+		cached.DynamicPermissions[0] = "admin:*"
+		cached.Claims.Roles[0] = "admin"
+	}
+}
+
+func TestValidationCacheRechecksForceLogout(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{RedisClient: redisClient, PublicKeyPath: path, ValidationCacheTTL: time.Minute})
+
+	claims := testClaims("42")
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	token := signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+	ctx := context.Background()
+
+	if _, err := client.ValidateTokenWithDynamicAuth(ctx, token); err != nil {
+		t.Fatalf("first validation: %v", err)
+	}
+	if result, err := client.ValidateTokenWithDynamicAuth(ctx, token); err != nil || !result.ValidatedFromCache {
+		t.Fatalf("second validation = %+v, %v; want cache hit", result, err)
+	}
+
+	// 其他服務直接寫入 Redis 的強制登出標記，不會經過本客戶端清除快取
+	server.Set("user:force_logout:42", fmt.Sprint(time.Now().Unix()))
+
+	result, err := client.ValidateTokenWithDynamicAuth(ctx, token)
+	if err != nil {
+		t.Fatalf("validation after force logout: %v", err)
+	}
+	if !result.ShouldForceLogout || result.ValidatedFromCache {
+		t.Errorf("result = %+v, want a fresh force-logout result", result)
+	}
+	if stats := client.ValidationCacheStats(); stats.Size != 0 {
+		t.Errorf("cache size = %d, want 0 after force logout", stats.Size)
+	}
+}
+
+func TestValidateTokenOverloaded(t *testing.T) {
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: path, MaxConcurrentValidations: 1})
+	token := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("42"), nil)
+
+	// 佔住唯一的名額
+	client.validationSem <- struct{}{}
+	_, err := client.ValidateToken(token)
+	<-client.validationSem
+
+	if !errors.Is(err, ErrValidationOverloaded) || errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want only ErrValidationOverloaded", err)
+	}
+	if _, err := client.ValidateToken(token); err != nil {
+		t.Fatalf("ValidateToken after slot freed: %v", err)
+	}
+}
+
+func TestNewClientWithStoreSkipsRedis(t *testing.T) {
+	_, redisClient := newTestRedis(t)
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{
+		PublicKeyPath: path,
+		Store:         NewRedisStore(redisClient, RedisStoreConfig{}),
+		RedisAddr:     "127.0.0.1:1",
+		RedisReadAddr: "127.0.0.1:2",
+	})
+
+	if client.RedisClient() != nil {
+		t.Fatal("RedisClient() is set although only Store was configured")
+	}
+
+	report := client.CheckHealth(context.Background())
+	if _, ok := report.Dependencies[HealthDependencyRedis]; ok {
+		t.Errorf("CheckHealth pinged Redis: %+v", report.Dependencies)
+	}
+	if _, ok := report.Dependencies[HealthDependencyRedisRead]; ok {
+		t.Errorf("CheckHealth pinged the Redis replica: %+v", report.Dependencies)
+	}
+
+	claims := testClaims("42")
+	claims.ID = "once"
+	token := signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+	if _, err := client.ConsumeOnceToken(context.Background(), token); err != nil {
+		t.Errorf("ConsumeOnceToken through Store: %v", err)
+	}
+
+	if _, err := client.ValidateTokenWithDynamicAuth(context.Background(), token); err != nil {
+		t.Errorf("ValidateTokenWithDynamicAuth through Store: %v", err)
+	}
+}
+
+func TestValidateTokenAudience(t *testing.T) {
+	key, path := newRSAKey(t)
+
+	tests := []struct {
+		name     string
+		expected []string
+		aud      interface{} // 原樣寫入 aud 聲明；nil 表示不帶 aud
+		wantErr  bool
+	}{
+		{name: "single string match", expected: []string{"cdn"}, aud: "cdn"},
+		{name: "single string mismatch", expected: []string{"cdn"}, aud: "billing", wantErr: true},
+		{name: "array match", expected: []string{"cdn"}, aud: []string{"billing", "cdn"}},
+		{name: "array no intersection", expected: []string{"cdn", "cdn.example.com"}, aud: []string{"billing", "dns"}, wantErr: true},
+		{name: "any of several expected", expected: []string{"cdn", "cdn.example.com"}, aud: "cdn.example.com"},
+		{name: "empty array", expected: []string{"cdn"}, aud: []string{}, wantErr: true},
+		{name: "missing aud", expected: []string{"cdn"}, aud: nil, wantErr: true},
+		{name: "no expectation", expected: nil, aud: "billing"},
+		{name: "no expectation without aud", expected: nil, aud: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{PublicKeyPath: path, ExpectedAudience: tt.expected})
+
+			now := time.Now()
+			claims := jwt.MapClaims{
+				"user_id":    "42",
+				"token_type": TokenTypeAccess,
+				"iss":        testIssuer,
+				"iat":        now.Unix(),
+				"exp":        now.Add(time.Hour).Unix(),
+			}
+			if tt.aud != nil {
+				claims["aud"] = tt.aud
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+			if err != nil {
+				t.Fatalf("sign token: %v", err)
+			}
+
+			_, err = client.ValidateToken(token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAudience) {
+					t.Fatalf("err = %v, want ErrInvalidAudience", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkValidateInvalidToken(b *testing.B) {
+	_, path := newRSAKey(b)
+	forgedKey, _ := newRSAKey(b)
+	token := signTestToken(b, jwt.SigningMethodRS256, forgedKey, testClaims("42"), nil)
+
+	for _, bench := range []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{name: "negative cache off"},
+		{name: "negative cache on", ttl: time.Minute},
+	} {
+		client := newTestClient(b, &Config{PublicKeyPath: path, NegativeCacheTTL: bench.ttl})
+		b.Run(bench.name, func(b *testing.B) {
+			ctx := context.Background()
+			// 先驗證一次，讓啟用快取時量測的是命中後的成本
+			_, _ = client.ValidateTokenWithDynamicAuth(ctx, token)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.ValidateTokenWithDynamicAuth(ctx, token); !errors.Is(err, ErrInvalidSignature) {
+					b.Fatalf("err = %v, want ErrInvalidSignature", err)
+				}
+			}
+			b.StopTimer()
+
+			if bench.ttl > 0 && client.NegativeCacheStats().Hits == 0 {
+				b.Error("negative cache was never hit")
+			}
+		})
+	}
+}
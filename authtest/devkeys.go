@@ -0,0 +1,122 @@
+// Package authtest 提供本機開發與範例測試用的輔助工具
+// 產生金鑰對並回傳可直接使用的 auth.Config，降低首次啟動的設定成本
+package authtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// DevIssuer 開發環境使用的 JWT 發行者
+	DevIssuer = "devops-portal-dev"
+	// DevRedisAddr 開發環境預設的 Redis 地址
+	DevRedisAddr = "localhost:6379"
+
+	// PrivateKeyFile 產生的私鑰檔名
+	PrivateKeyFile = "private_key.pem"
+	// PublicKeyFile 產生的公鑰檔名
+	PublicKeyFile = "public_key.pem"
+)
+
+// GenerateDevKeys 在 dir 產生 RSA 金鑰對，並回傳對應的開發用設定
+// 私鑰寫入 private_key.pem（PKCS#8），公鑰寫入 public_key.pem（PKIX）
+func GenerateDevKeys(dir string) (*auth.Config, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	privatePath := filepath.Join(dir, PrivateKeyFile)
+	if err := writePEM(privatePath, "PRIVATE KEY", privateDER, 0o600); err != nil {
+		return nil, err
+	}
+	publicPath := filepath.Join(dir, PublicKeyFile)
+	if err := writePEM(publicPath, "PUBLIC KEY", publicDER, 0o644); err != nil {
+		return nil, err
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	return &auth.Config{
+		PublicKeyPath: publicPath,
+		Issuer:        DevIssuer,
+		RedisAddr:     DevRedisAddr,
+		Logger:        logger,
+	}, nil
+}
+
+// SignToken 使用 GenerateDevKeys 產生的私鑰簽發 token
+// 未設定發行者時自動填入 DevIssuer
+func SignToken(dir string, claims *auth.Claims) (string, error) {
+	keyData, err := os.ReadFile(filepath.Join(dir, PrivateKeyFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	block, _ := pem.Decode(keyData)
+	if block == nil {
+		return "", errors.New("failed to decode PEM block containing private key")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	if claims.Issuer == "" {
+		claims.Issuer = DevIssuer
+	}
+
+	// 依私鑰類型選擇簽名方法
+	var method jwt.SigningMethod
+	switch privateKey.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+
+	return jwt.NewWithClaims(method, claims).SignedString(privateKey)
+}
+
+// writePEM 將 DER 內容以 PEM 格式寫入檔案
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package authtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// newDevClient 以 GenerateDevKeys 產生的設定建立連線至 miniredis 的客戶端
+func newDevClient(t *testing.T, config *auth.Config) *auth.Client {
+	t.Helper()
+	config.RedisAddr = miniredis.RunT(t).Addr()
+	config.Logger = zap.NewNop()
+
+	client, err := auth.NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGenerateDevKeysWritesKeyFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	config, err := GenerateDevKeys(dir)
+	if err != nil {
+		t.Fatalf("GenerateDevKeys: %v", err)
+	}
+
+	if config.PublicKeyPath != filepath.Join(dir, PublicKeyFile) {
+		t.Errorf("PublicKeyPath = %q", config.PublicKeyPath)
+	}
+	if config.Issuer != DevIssuer {
+		t.Errorf("Issuer = %q, want %q", config.Issuer, DevIssuer)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, PrivateKeyFile))
+	if err != nil {
+		t.Fatalf("private key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("private key permissions = %o, want 600", perm)
+	}
+}
+
+func TestSignTokenRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	config, err := GenerateDevKeys(dir)
+	if err != nil {
+		t.Fatalf("GenerateDevKeys: %v", err)
+	}
+	client := newDevClient(t, config)
+
+	token, err := SignToken(dir, &auth.Claims{
+		UserID:      "42",
+		Username:    "dev",
+		Permissions: []string{"user:read"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+
+	claims, err := client.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != "42" || claims.Issuer != DevIssuer {
+		t.Errorf("claims = %+v", claims)
+	}
+	if len(claims.Permissions) != 1 || claims.Permissions[0] != "user:read" {
+		t.Errorf("permissions = %q", claims.Permissions)
+	}
+}
+
+func TestSignTokenRejectedByOtherKeys(t *testing.T) {
+	signingDir := t.TempDir()
+	if _, err := GenerateDevKeys(signingDir); err != nil {
+		t.Fatalf("GenerateDevKeys: %v", err)
+	}
+	config, err := GenerateDevKeys(t.TempDir())
+	if err != nil {
+		t.Fatalf("GenerateDevKeys: %v", err)
+	}
+	client := newDevClient(t, config)
+
+	token, err := SignToken(signingDir, &auth.Claims{
+		UserID: "42",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+
+	if _, err := client.ValidateToken(token); !errors.Is(err, auth.ErrInvalidSignature) {
+		t.Fatalf("ValidateToken err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSignTokenMissingKey(t *testing.T) {
+	if _, err := SignToken(t.TempDir(), &auth.Claims{}); err == nil {
+		t.Fatal("SignToken succeeded without a private key")
+	}
+}
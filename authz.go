@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuthzCheck 單一授權條件，要求指定角色或權限（二擇一設定）
+type AuthzCheck struct {
+	Role       string // 需要的角色
+	Permission string // 需要的權限，支援萬用字元
+}
+
+// RoleCheck 建立角色條件
+func RoleCheck(role string) AuthzCheck {
+	return AuthzCheck{Role: role}
+}
+
+// PermissionCheck 建立權限條件
+func PermissionCheck(permission string) AuthzCheck {
+	return AuthzCheck{Permission: permission}
+}
+
+// String 回傳條件描述，用於日誌與錯誤訊息
+func (a AuthzCheck) String() string {
+	if a.Role != "" {
+		return "role:" + a.Role
+	}
+	return "permission:" + a.Permission
+}
+
+// RequireAny 任一條件成立即放行的中介軟體（可混合角色與權限條件）
+func (m *GinMiddleware) RequireAny(checks ...AuthzCheck) gin.HandlerFunc {
+	descriptions := make([]string, len(checks))
+	for i, check := range checks {
+		descriptions[i] = check.String()
+		m.registry.addExpr(check)
+	}
+
+	return func(c *gin.Context) {
+		roles := contextStrings(c, ContextKeyRoles)
+		permissions := contextStrings(c, ContextKeyPermissions)
+
+		for _, check := range checks {
+			if m.evaluateCheck(check, roles, permissions) {
+				c.Next()
+				return
+			}
+		}
+
+		m.logger.Info("Authorization denied",
+			zap.String("user_id", m.getUserID(c)),
+			zap.Strings("required_any", descriptions),
+			zap.Strings("user_roles", roles),
+			zap.Strings("user_permissions", permissions))
+
+		m.denyPermission(c, descriptions, "Insufficient permissions: required one of ["+strings.Join(descriptions, ", ")+"]")
+	}
+}
+
+// evaluateCheck 判斷單一條件是否成立
+func (m *GinMiddleware) evaluateCheck(check AuthzCheck, roles, permissions []string) bool {
+	return check.Eval(AuthzContext{Roles: roles, Permissions: permissions})
+}
+
+// Eval 實作 Expr，讓單一條件可直接組合進授權表達式
+func (a AuthzCheck) Eval(ctx AuthzContext) bool {
+	if a.Role != "" {
+		for _, role := range ctx.Roles {
+			if role == a.Role {
+				return true
+			}
+		}
+		return false
+	}
+	if a.Permission != "" {
+		return hasPermission(ctx.Permissions, a.Permission)
+	}
+	return false
+}
+
+// AuthzContext 授權表達式的評估上下文
+type AuthzContext struct {
+	Roles       []string
+	Permissions []string
+}
+
+// Expr 授權表達式，可透過 And/Or/Not 組合
+// 例如 Allow(And(Or(HasRole("admin"), HasPermission("billing:write")), Not(HasRole("suspended"))))
+type Expr interface {
+	Eval(ctx AuthzContext) bool
+	String() string
+}
+
+// HasRole 要求指定角色的表達式
+func HasRole(role string) Expr {
+	return RoleCheck(role)
+}
+
+// HasPermission 要求指定權限的表達式
+func HasPermission(permission string) Expr {
+	return PermissionCheck(permission)
+}
+
+// And 所有子表達式皆成立（無子表達式時成立）
+func And(exprs ...Expr) Expr {
+	return andExpr(exprs)
+}
+
+// Or 任一子表達式成立（無子表達式時不成立）
+func Or(exprs ...Expr) Expr {
+	return orExpr(exprs)
+}
+
+// Not 子表達式不成立
+func Not(expr Expr) Expr {
+	return notExpr{expr: expr}
+}
+
+// Allow 標示頂層授權策略，nil 表達式一律拒絕
+func Allow(expr Expr) Expr {
+	if expr == nil {
+		return denyExpr{}
+	}
+	return expr
+}
+
+type andExpr []Expr
+
+func (e andExpr) Eval(ctx AuthzContext) bool {
+	for _, expr := range e {
+		if expr == nil || !expr.Eval(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e andExpr) String() string {
+	return joinExprs(e, " AND ")
+}
+
+type orExpr []Expr
+
+func (e orExpr) Eval(ctx AuthzContext) bool {
+	for _, expr := range e {
+		if expr != nil && expr.Eval(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e orExpr) String() string {
+	return joinExprs(e, " OR ")
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e notExpr) Eval(ctx AuthzContext) bool {
+	// nil 子表達式視為不成立的條件，避免 Not(nil) 意外放行
+	return e.expr != nil && !e.expr.Eval(ctx)
+}
+
+func (e notExpr) String() string {
+	if e.expr == nil {
+		return "NOT <nil>"
+	}
+	return "NOT " + e.expr.String()
+}
+
+type denyExpr struct{}
+
+func (denyExpr) Eval(AuthzContext) bool { return false }
+
+func (denyExpr) String() string { return "DENY" }
+
+// joinExprs 以運算子連接子表達式描述
+func joinExprs(exprs []Expr, op string) string {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		if expr == nil {
+			parts[i] = "<nil>"
+			continue
+		}
+		parts[i] = expr.String()
+	}
+	return "(" + strings.Join(parts, op) + ")"
+}
+
+// RequireExpr 依授權表達式判斷是否放行的中介軟體
+func (m *GinMiddleware) RequireExpr(expr Expr) gin.HandlerFunc {
+	expr = Allow(expr)
+	description := expr.String()
+	m.registry.addExpr(expr)
+
+	return func(c *gin.Context) {
+		ctx := AuthzContext{
+			Roles:       contextStrings(c, ContextKeyRoles),
+			Permissions: contextStrings(c, ContextKeyPermissions),
+		}
+
+		if expr.Eval(ctx) {
+			c.Next()
+			return
+		}
+
+		m.logger.Info("Authorization denied",
+			zap.String("user_id", m.getUserID(c)),
+			zap.String("policy", description),
+			zap.Strings("user_roles", ctx.Roles),
+			zap.Strings("user_permissions", ctx.Permissions))
+
+		m.denyPermission(c, []string{description}, "Insufficient permissions: policy "+description+" not satisfied")
+	}
+}
+
+// contextStrings 從 gin.Context 取得字串切片，不存在或型別不符時回傳 nil
+func contextStrings(c *gin.Context, key string) []string {
+	if value, exists := c.Get(key); exists {
+		if values, ok := value.([]string); ok {
+			return values
+		}
+	}
+	return nil
+}
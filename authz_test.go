@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// withUser 模擬 Authenticate，將角色與權限寫入上下文
+func withUser(roles, permissions []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ContextKeyUserID, "42")
+		c.Set(ContextKeyRoles, roles)
+		c.Set(ContextKeyPermissions, permissions)
+		c.Next()
+	}
+}
+
+func TestExprEval(t *testing.T) {
+	admin := AuthzContext{Roles: []string{"admin"}, Permissions: []string{"billing:read"}}
+	suspendedAdmin := AuthzContext{Roles: []string{"admin", "suspended"}}
+	billing := AuthzContext{Permissions: []string{"billing:*"}}
+	nobody := AuthzContext{}
+
+	policy := And(Or(HasRole("admin"), HasPermission("billing:write")), Not(HasRole("suspended")))
+
+	tests := []struct {
+		name string
+		expr Expr
+		ctx  AuthzContext
+		want bool
+	}{
+		{name: "role present", expr: HasRole("admin"), ctx: admin, want: true},
+		{name: "role absent", expr: HasRole("admin"), ctx: billing, want: false},
+		{name: "permission exact", expr: HasPermission("billing:read"), ctx: admin, want: true},
+		{name: "permission wildcard", expr: HasPermission("billing:write"), ctx: billing, want: true},
+		{name: "empty check", expr: AuthzCheck{}, ctx: admin, want: false},
+		{name: "and all true", expr: And(HasRole("admin"), HasPermission("billing:read")), ctx: admin, want: true},
+		{name: "and one false", expr: And(HasRole("admin"), HasPermission("billing:write")), ctx: admin, want: false},
+		{name: "and empty", expr: And(), ctx: nobody, want: true},
+		{name: "and nil child", expr: And(HasRole("admin"), nil), ctx: admin, want: false},
+		{name: "or one true", expr: Or(HasRole("ops"), HasRole("admin")), ctx: admin, want: true},
+		{name: "or all false", expr: Or(HasRole("ops"), HasRole("dev")), ctx: admin, want: false},
+		{name: "or empty", expr: Or(), ctx: admin, want: false},
+		{name: "or nil child", expr: Or(nil), ctx: admin, want: false},
+		{name: "not true", expr: Not(HasRole("suspended")), ctx: admin, want: true},
+		{name: "not false", expr: Not(HasRole("admin")), ctx: admin, want: false},
+		{name: "not nil", expr: Not(nil), ctx: admin, want: false},
+		{name: "allow nil", expr: Allow(nil), ctx: admin, want: false},
+		{name: "policy admin", expr: policy, ctx: admin, want: true},
+		{name: "policy billing writer", expr: policy, ctx: billing, want: true},
+		{name: "policy suspended admin", expr: policy, ctx: suspendedAdmin, want: false},
+		{name: "policy nobody", expr: policy, ctx: nobody, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.Eval(tt.ctx); got != tt.want {
+				t.Errorf("%s.Eval() = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprString(t *testing.T) {
+	tests := []struct {
+		expr Expr
+		want string
+	}{
+		{expr: HasRole("admin"), want: "role:admin"},
+		{expr: HasPermission("billing:write"), want: "permission:billing:write"},
+		{expr: And(HasRole("admin"), Not(HasRole("suspended"))), want: "(role:admin AND NOT role:suspended)"},
+		{expr: Or(HasRole("admin"), nil), want: "(role:admin OR <nil>)"},
+		{expr: Not(nil), want: "NOT <nil>"},
+		{expr: Allow(nil), want: "DENY"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.expr.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRequireExpr(t *testing.T) {
+	policy := And(Or(HasRole("admin"), HasPermission("billing:write")), Not(HasRole("suspended")))
+
+	tests := []struct {
+		name        string
+		expr        Expr
+		roles       []string
+		permissions []string
+		wantStatus  int
+	}{
+		{name: "allowed by role", expr: policy, roles: []string{"admin"}, wantStatus: http.StatusOK},
+		{name: "allowed by permission", expr: policy, permissions: []string{"billing:write"}, wantStatus: http.StatusOK},
+		{name: "denied by not", expr: policy, roles: []string{"admin", "suspended"}, wantStatus: http.StatusForbidden},
+		{name: "denied without grants", expr: policy, wantStatus: http.StatusForbidden},
+		{name: "nil expression denies", expr: nil, roles: []string{"admin"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+
+			w, reached := serve(req, withUser(tt.roles, tt.permissions), m.RequireExpr(tt.expr))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v", reached)
+			}
+		})
+	}
+}
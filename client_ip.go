@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIPResolver 依信任的代理清單從 X-Forwarded-For 解析真實用戶端 IP
+// 供速率限制、IP 過濾與日誌等依賴用戶端 IP 的功能共用
+//
+// X-Forwarded-For 可由用戶端任意偽造，因此只有直接連線來源屬於信任代理時才會讀取，
+// 並由右至左略過信任代理，回傳第一個不受信任的位址；信任清單應僅包含自家負載平衡器
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver 建立 IP 解析器，trustedProxies 可為 IP 或 CIDR（如 10.0.0.0/8）
+// 清單為空時一律使用直接連線來源，不讀取 X-Forwarded-For
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			r.trusted = append(r.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, network)
+	}
+	return r, nil
+}
+
+// ClientIP 回傳 Gin 請求的用戶端 IP；resolver 為 nil 時沿用 gin 的 c.ClientIP()
+func (r *ClientIPResolver) ClientIP(c *gin.Context) string {
+	if r == nil {
+		return c.ClientIP()
+	}
+	return r.Resolve(c.Request)
+}
+
+// Resolve 驗證代理鏈後回傳請求的用戶端 IP
+func (r *ClientIPResolver) Resolve(req *http.Request) string {
+	remote := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	ip := net.ParseIP(remote)
+	if ip == nil || !r.isTrusted(ip) {
+		return remote // 直接連線來源不是信任代理，X-Forwarded-For 不可信
+	}
+
+	// 合併多個 X-Forwarded-For 標頭，由右至左略過信任代理
+	var hops []string
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break // 格式錯誤的代理鏈，停止解析
+		}
+		if !r.isTrusted(hop) {
+			return hop.String()
+		}
+		remote = hop.String()
+	}
+
+	// 整條鏈都是信任代理時，回傳最左側的位址
+	return remote
+}
+
+// isTrusted 判斷 IP 是否屬於信任代理
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
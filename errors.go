@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token 驗證錯誤，ValidateToken 以 %w 包裝，可透過 errors.Is 判斷失敗原因
+var (
+	// ErrTokenExpired token 已過期
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotValidYet token 尚未生效（nbf）
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	// ErrTokenMalformed token 格式錯誤，無法解析
+	ErrTokenMalformed = errors.New("token is malformed")
+	// ErrInvalidSignature 簽名無效、簽名方法不允許或找不到對應的公鑰
+	ErrInvalidSignature = errors.New("token signature is invalid")
+	// ErrInvalidIssuer 發行者不被接受
+	ErrInvalidIssuer = errors.New("invalid token issuer")
+	// ErrInvalidAudience 受眾不符
+	ErrInvalidAudience = errors.New("invalid token audience")
+	// ErrWrongTokenType token 類型不符（如以 refresh token 作為 access token 使用）
+	ErrWrongTokenType = errors.New("wrong token type")
+	// ErrValidationOverloaded 同時驗證數已達 MaxConcurrentValidations 上限
+	ErrValidationOverloaded = errors.New("too many concurrent token validations")
+	// ErrDynamicAuthUnavailable 啟用 FailClosed 時，無法確認用戶狀態或強制登出標記
+	ErrDynamicAuthUnavailable = errors.New("dynamic auth checks unavailable")
+	// ErrTokenAlreadyUsed 一次性 token 已被使用過（ConsumeOnceToken）
+	ErrTokenAlreadyUsed = errors.New("token has already been used")
+	// ErrMissingTokenID token 缺少 jti 或 exp，無法作為一次性 token 使用
+	ErrMissingTokenID = errors.New("one-time token requires jti and exp claims")
+	// ErrOnceTokenUnsupported 權限儲存未實作 OnceTokenStore，無法使用 ConsumeOnceToken
+	ErrOnceTokenUnsupported = errors.New("store does not support one-time tokens")
+	// ErrRedisNotConfigured 設定 Store 且未提供 RedisClient 時，直接使用 Redis 的功能無法使用
+	ErrRedisNotConfigured = errors.New("no redis client configured")
+)
+
+// mapParseError 將 jwt 函式庫的解析錯誤對應為 SDK 的錯誤類型，保留原始錯誤供 errors.Is 判斷
+func mapParseError(err error) error {
+	switch {
+	case errors.Is(err, ErrValidationOverloaded):
+		return ErrValidationOverloaded
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return fmt.Errorf("%w: %w", ErrTokenNotValidYet, err)
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	case errors.Is(err, ErrInvalidIssuer):
+		return fmt.Errorf("%w: %w", ErrInvalidIssuer, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	default:
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+}
+
+// isPermanentTokenError 判斷 token 驗證錯誤是否不會隨時間改變，可安全地快取（見 Config.NegativeCacheTTL）
+// 找不到 kid 等金鑰查詢失敗同樣對應 ErrInvalidSignature，故以 jwt.ErrTokenSignatureInvalid 區分真正的簽名不符
+func isPermanentTokenError(err error) bool {
+	switch {
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, ErrTokenMalformed),
+		errors.Is(err, ErrTokenExpired),
+		errors.Is(err, ErrInvalidIssuer),
+		errors.Is(err, ErrInvalidAudience),
+		errors.Is(err, ErrWrongTokenType):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 驗證失敗原因分類
+const (
+	FailureReasonMissingToken  = "missing_token"
+	FailureReasonInvalidFormat = "invalid_format"
+	FailureReasonInvalidToken  = "invalid_token"
+	FailureReasonTokenExpired  = "token_expired"
+	FailureReasonUserDisabled  = "user_disabled"
+	FailureReasonForceLogout   = "force_logout"
+	FailureReasonOverloaded    = "overloaded"
+	FailureReasonUnavailable   = "auth_unavailable"
+)
+
+// FailureSinkConfig 驗證失敗事件輸出設定
+type FailureSinkConfig struct {
+	Stream        string // Redis Stream 名稱，預設 auth:failures
+	MaxLen        int64  // Stream 近似長度上限，預設 100000
+	RatePerSecond int    // 每秒最多寫入的事件數，超出時捨棄，預設 100
+	BufferSize    int    // 待寫入事件的緩衝大小，滿載時捨棄，預設 1000
+}
+
+// FailureEvent 驗證失敗事件
+type FailureEvent struct {
+	Reason      string
+	UserID      string
+	ClientIP    string
+	UserAgent   string
+	TokenPrefix string
+	Method      string
+	Path        string
+	Timestamp   time.Time
+}
+
+// FailureSink 將驗證失敗事件非同步寫入 Redis Stream，供安全分析使用
+// Record 不會阻塞請求；超出速率或緩衝已滿的事件直接捨棄
+type FailureSink struct {
+	redisClient redis.Cmdable
+	config      FailureSinkConfig
+	logger      *zap.Logger
+
+	events  chan FailureEvent
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// closeMu 讓 Record 的關閉檢查與送入緩衝成為不可分割的操作，
+	// Flush 取得寫鎖設置 closed 後不會再有事件送入，清空緩衝時不會遺漏未計數的事件
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+
+	dropped atomic.Int64
+}
+
+// NewFailureSink 建立使用客戶端 Redis 連線的失敗事件輸出器
+// 輸出器會向客戶端註冊，於 Shutdown/Close 時清空緩衝
+func (c *Client) NewFailureSink(config FailureSinkConfig) *FailureSink {
+	if config.Stream == "" {
+		config.Stream = "auth:failures"
+	}
+	if config.MaxLen <= 0 {
+		config.MaxLen = 100000
+	}
+	if config.RatePerSecond <= 0 {
+		config.RatePerSecond = 100
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1000
+	}
+
+	sink := &FailureSink{
+		redisClient: c.redisClient,
+		config:      config,
+		logger:      c.logger,
+		events:      make(chan FailureEvent, config.BufferSize),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	go sink.run()
+
+	c.registerBuffered(sink)
+	return sink
+}
+
+// Record 記錄一筆失敗事件（非阻塞）
+func (s *FailureSink) Record(event FailureEvent) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+
+	if !s.allow(time.Now()) {
+		s.dropped.Add(1)
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped 回傳因限流、緩衝滿載或已關閉而捨棄的事件數
+func (s *FailureSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Name 實作 bufferedComponent
+func (s *FailureSink) Name() string {
+	return "failure_sink"
+}
+
+// Flush 停止背景寫入並送出緩衝中剩餘的事件，ctx 到期後的事件計為捨棄
+func (s *FailureSink) Flush(ctx context.Context) (flushed, dropped int) {
+	s.once.Do(func() {
+		s.closeMu.Lock()
+		s.closed = true
+		s.closeMu.Unlock()
+		close(s.done)
+	})
+	<-s.stopped
+
+	for {
+		select {
+		case event := <-s.events:
+			if ctx.Err() != nil || s.write(ctx, event) != nil {
+				dropped++
+				continue
+			}
+			flushed++
+		default:
+			return flushed, dropped
+		}
+	}
+}
+
+// run 背景寫入事件
+func (s *FailureSink) run() {
+	defer close(s.stopped)
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case event := <-s.events:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			if err := s.write(ctx, event); err != nil {
+				s.dropped.Add(1)
+				s.logger.Debug("Failed to write auth failure event", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// write 以 XADD 寫入單筆事件
+func (s *FailureSink) write(ctx context.Context, event FailureEvent) error {
+	if s.redisClient == nil {
+		return ErrRedisNotConfigured
+	}
+	return s.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.config.Stream,
+		MaxLen: s.config.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"reason":       event.Reason,
+			"user_id":      event.UserID,
+			"client_ip":    event.ClientIP,
+			"user_agent":   event.UserAgent,
+			"token_prefix": event.TokenPrefix,
+			"method":       event.Method,
+			"path":         event.Path,
+			"timestamp":    event.Timestamp.Unix(),
+		},
+	}).Err()
+}
+
+// allow 每秒固定視窗限流，避免攻擊期間灌爆 Stream
+func (s *FailureSink) allow(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	if s.windowCount >= s.config.RatePerSecond {
+		return false
+	}
+	s.windowCount++
+	return true
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestFailureSinkFlushAccountsForEveryEvent(t *testing.T) {
+	_, redisClient := newTestRedis(t)
+	client := newTestClient(t, &Config{RedisClient: redisClient, PublicKeyPath: mustRSAKeyPath(t)})
+
+	sink := client.NewFailureSink(FailureSinkConfig{Stream: "auth:failures", RatePerSecond: 1 << 20, BufferSize: 64})
+
+	// Flush 與多個寫入端同時進行，涵蓋 Record 與 Flush 交錯的時序
+	const writers, perWriter = 8, 200
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				sink.Record(FailureEvent{Reason: FailureReasonInvalidToken})
+			}
+		}()
+	}
+
+	_, flushDropped := sink.Flush(context.Background())
+	wg.Wait()
+
+	written, err := redisClient.XLen(context.Background(), "auth:failures").Result()
+	if err != nil {
+		t.Fatalf("XLEN: %v", err)
+	}
+
+	// 每筆事件都必須寫入 Stream 或計入捨棄數
+	total := written + sink.Dropped() + int64(flushDropped)
+	if total != writers*perWriter {
+		t.Fatalf("written %d + dropped %d + flush dropped %d = %d, want %d",
+			written, sink.Dropped(), flushDropped, total, writers*perWriter)
+	}
+}
+
+func TestFailureSinkRecordAfterFlushIsDropped(t *testing.T) {
+	_, redisClient := newTestRedis(t)
+	client := newTestClient(t, &Config{RedisClient: redisClient, PublicKeyPath: mustRSAKeyPath(t)})
+	sink := client.NewFailureSink(FailureSinkConfig{})
+
+	sink.Flush(context.Background())
+	sink.Record(FailureEvent{Reason: FailureReasonMissingToken})
+
+	if got := sink.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+}
+
+// mustRSAKeyPath 產生測試用 RSA 公鑰檔並回傳路徑
+func mustRSAKeyPath(t *testing.T) string {
+	_, path := newRSAKey(t)
+	return path
+}
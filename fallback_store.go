@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 內建層級（主要儲存與 Auth 服務 HTTP 層）的預設值
+const (
+	defaultTierTimeout  = 2 * time.Second
+	defaultTierCooldown = 5 * time.Second
+)
+
+// errTierCoolingDown 層級仍在失敗後的冷卻期間，本次查詢未嘗試
+var errTierCoolingDown = errors.New("tier is cooling down after a failure")
+
+// StoreTier 降級鏈中的一層儲存
+type StoreTier struct {
+	Name  string // 統計與日誌使用的名稱，如 redis、http
+	Store PermissionStore
+
+	// Timeout 單次查詢此層的時間上限，逾時即改由下一層處理（0 表示僅受呼叫端 ctx 限制）
+	Timeout time.Duration
+	// Cooldown 此層查詢失敗後暫停嘗試的時間，期間直接改由下一層處理，
+	// 避免儲存中斷時每個檢查都各自等待逾時（0 表示每次都嘗試；資料損壞不會觸發冷卻）
+	Cooldown time.Duration
+}
+
+// TierStats 單一層級的使用統計
+type TierStats struct {
+	Served   uint64 `json:"served"`   // 由此層回應的查詢數（含資料不存在；批次查詢依用戶計算）
+	Failures uint64 `json:"failures"` // 此層查詢失敗、改由下一層處理的次數
+	Skipped  uint64 `json:"skipped"`  // 冷卻期間略過此層的次數
+}
+
+// tierCounters 單一層級的計數器與冷卻狀態
+type tierCounters struct {
+	served   atomic.Uint64
+	failures atomic.Uint64
+	skipped  atomic.Uint64
+
+	coolingUntil atomic.Int64 // 冷卻結束時間（Unix 奈秒），0 表示未冷卻
+}
+
+// FallbackStore 依序嘗試多層儲存的 PermissionStore
+// 查詢時若某層發生錯誤（資料不存在除外）、逾時或仍在冷卻期間，改由下一層回應；所有層都失敗時回傳最後的錯誤，
+// 由 Client 套用 JWT 權限與容錯預設值作為最終層
+// 寫入僅送往第一層；同時實作 BatchPermissionStore，各層支援時以單次請求批次查詢
+type FallbackStore struct {
+	tiers    []StoreTier
+	counters []*tierCounters
+	logger   *zap.Logger
+}
+
+// NewFallbackStore 建立降級鏈，tiers 依優先順序排列
+func NewFallbackStore(logger *zap.Logger, tiers ...StoreTier) (*FallbackStore, error) {
+	if len(tiers) == 0 {
+		return nil, errors.New("fallback store requires at least one tier")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	counters := make([]*tierCounters, len(tiers))
+	for i, tier := range tiers {
+		if tier.Store == nil {
+			return nil, fmt.Errorf("fallback store tier %q has no store", tier.Name)
+		}
+		counters[i] = &tierCounters{}
+	}
+
+	return &FallbackStore{
+		tiers:    tiers,
+		counters: counters,
+		logger:   logger,
+	}, nil
+}
+
+// Stats 回傳各層的使用統計
+func (s *FallbackStore) Stats() map[string]TierStats {
+	stats := make(map[string]TierStats, len(s.tiers))
+	for i, tier := range s.tiers {
+		stats[tier.Name] = TierStats{
+			Served:   s.counters[i].served.Load(),
+			Failures: s.counters[i].failures.Load(),
+			Skipped:  s.counters[i].skipped.Load(),
+		}
+	}
+	return stats
+}
+
+// query 依序嘗試各層，回傳第一個成功或資料不存在的結果
+func (s *FallbackStore) query(ctx context.Context, operation, userID string, fn func(context.Context, PermissionStore) error) error {
+	var lastErr error
+	for i, tier := range s.tiers {
+		if s.coolingDown(i) {
+			s.counters[i].skipped.Add(1)
+			lastErr = fmt.Errorf("%s: %w", tier.Name, errTierCoolingDown)
+			continue
+		}
+
+		tierCtx, cancel := tierContext(ctx, tier)
+		err := fn(tierCtx, tier.Store)
+		cancel()
+		if err == nil || errors.Is(err, ErrCacheNotFound) {
+			s.counters[i].served.Add(1)
+			return err
+		}
+
+		s.tierFailed(ctx, i, operation, userID, err)
+		lastErr = fmt.Errorf("%s: %w", tier.Name, err)
+	}
+	return lastErr
+}
+
+// queryBatch 依序以各層批次查詢，該層未能回應的用戶改由下一層處理
+// 層級支援 BatchPermissionStore 時以 batch 單次查詢，否則以 single 逐一查詢
+func queryBatch[V any](
+	ctx context.Context,
+	s *FallbackStore,
+	operation string,
+	userIDs []string,
+	batch func(context.Context, BatchPermissionStore, []string) (map[string]V, error),
+	single func(context.Context, PermissionStore, string) (V, error),
+) (map[string]V, error) {
+	result := make(map[string]V, len(userIDs))
+	pending := userIDs
+	var lastErr error
+	for i, tier := range s.tiers {
+		if len(pending) == 0 {
+			break
+		}
+		if s.coolingDown(i) {
+			s.counters[i].skipped.Add(1)
+			lastErr = fmt.Errorf("%s: %w", tier.Name, errTierCoolingDown)
+			continue
+		}
+
+		tierCtx, cancel := tierContext(ctx, tier)
+		var values map[string]V
+		var err error
+		if batchStore, ok := tier.Store.(BatchPermissionStore); ok {
+			values, err = batch(tierCtx, batchStore, pending)
+		} else {
+			values, err = queryEach(tierCtx, tier.Store, pending, single)
+		}
+		cancel()
+
+		var remaining []string
+		for _, userID := range pending {
+			if value, ok := values[userID]; ok {
+				result[userID] = value
+			} else {
+				remaining = append(remaining, userID)
+			}
+		}
+		s.counters[i].served.Add(uint64(len(pending) - len(remaining)))
+
+		if len(remaining) > 0 {
+			if err == nil {
+				err = errors.New("no result returned")
+			}
+			// 整批都未能回應才視為此層中斷而觸發冷卻，個別用戶的資料損壞不影響此層
+			if len(remaining) == len(pending) {
+				s.tierFailed(ctx, i, operation, "", err)
+			} else {
+				s.counters[i].failures.Add(1)
+			}
+			lastErr = fmt.Errorf("%s: %w", tier.Name, err)
+		}
+		pending = remaining
+	}
+
+	if len(pending) > 0 {
+		return result, lastErr
+	}
+	return result, nil
+}
+
+// queryEach 對不支援批次查詢的層級逐一查詢，資料不存在的用戶對應零值，查詢失敗的用戶不出現在結果中
+func queryEach[V any](ctx context.Context, store PermissionStore, userIDs []string, single func(context.Context, PermissionStore, string) (V, error)) (map[string]V, error) {
+	values := make(map[string]V, len(userIDs))
+	var errs []error
+	for _, userID := range userIDs {
+		value, err := single(ctx, store, userID)
+		switch {
+		case err == nil:
+			values[userID] = value
+		case errors.Is(err, ErrCacheNotFound):
+			var zero V
+			values[userID] = zero
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", userID, err))
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// tierContext 依 StoreTier.Timeout 建立單層查詢的 ctx
+func tierContext(ctx context.Context, tier StoreTier) (context.Context, context.CancelFunc) {
+	if tier.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, tier.Timeout)
+}
+
+// coolingDown 判斷層級是否仍在冷卻期間
+func (s *FallbackStore) coolingDown(i int) bool {
+	until := s.counters[i].coolingUntil.Load()
+	return until != 0 && time.Now().UnixNano() < until
+}
+
+// tierFailed 記錄層級失敗並依 Cooldown 暫停嘗試
+// 資料損壞或呼叫端取消不代表此層中斷，不觸發冷卻
+func (s *FallbackStore) tierFailed(ctx context.Context, i int, operation, userID string, err error) {
+	tier := s.tiers[i]
+	s.counters[i].failures.Add(1)
+	if tier.Cooldown > 0 && ctx.Err() == nil && !errors.Is(err, ErrCorruptCache) {
+		s.counters[i].coolingUntil.Store(time.Now().Add(tier.Cooldown).UnixNano())
+	}
+
+	if i < len(s.tiers)-1 {
+		s.logger.Warn("Permission store tier failed, falling back",
+			zap.String("tier", tier.Name),
+			zap.String("next_tier", s.tiers[i+1].Name),
+			zap.String("operation", operation),
+			zap.String("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// GetUserStatus 依序查詢用戶狀態
+func (s *FallbackStore) GetUserStatus(ctx context.Context, userID string) (*UserStatus, error) {
+	var status *UserStatus
+	err := s.query(ctx, "user_status", userID, func(ctx context.Context, store PermissionStore) error {
+		var err error
+		status, err = store.GetUserStatus(ctx, userID)
+		return err
+	})
+	return status, err
+}
+
+// GetForceLogout 依序查詢強制登出時間
+func (s *FallbackStore) GetForceLogout(ctx context.Context, userID string) (int64, error) {
+	var timestamp int64
+	err := s.query(ctx, "force_logout", userID, func(ctx context.Context, store PermissionStore) error {
+		var err error
+		timestamp, err = store.GetForceLogout(ctx, userID)
+		return err
+	})
+	return timestamp, err
+}
+
+// GetDynamicPermissions 依序查詢動態權限
+func (s *FallbackStore) GetDynamicPermissions(ctx context.Context, userID string) ([]string, error) {
+	var permissions []string
+	err := s.query(ctx, "dynamic_permissions", userID, func(ctx context.Context, store PermissionStore) error {
+		var err error
+		permissions, err = store.GetDynamicPermissions(ctx, userID)
+		return err
+	})
+	return permissions, err
+}
+
+// GetUserStatuses 依序批次查詢用戶狀態，資料不存在的用戶對應 nil
+func (s *FallbackStore) GetUserStatuses(ctx context.Context, userIDs []string) (map[string]*UserStatus, error) {
+	return queryBatch(ctx, s, "user_status", userIDs,
+		func(ctx context.Context, store BatchPermissionStore, userIDs []string) (map[string]*UserStatus, error) {
+			return store.GetUserStatuses(ctx, userIDs)
+		},
+		func(ctx context.Context, store PermissionStore, userID string) (*UserStatus, error) {
+			return store.GetUserStatus(ctx, userID)
+		})
+}
+
+// GetDynamicPermissionsBatch 依序批次查詢動態權限，資料不存在的用戶對應 nil
+func (s *FallbackStore) GetDynamicPermissionsBatch(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return queryBatch(ctx, s, "dynamic_permissions", userIDs,
+		func(ctx context.Context, store BatchPermissionStore, userIDs []string) (map[string][]string, error) {
+			return store.GetDynamicPermissionsBatch(ctx, userIDs)
+		},
+		func(ctx context.Context, store PermissionStore, userID string) ([]string, error) {
+			return store.GetDynamicPermissions(ctx, userID)
+		})
+}
+
+// GetDynamicPermissionsUpdatedAt 由第一層回報寫入時間
+func (s *FallbackStore) GetDynamicPermissionsUpdatedAt(ctx context.Context, userID string) (time.Time, error) {
+	ageStore, ok := s.tiers[0].Store.(PermissionAgeStore)
+	if !ok {
+		return time.Time{}, ErrCacheTimestampMissing
+	}
+	return ageStore.GetDynamicPermissionsUpdatedAt(ctx, userID)
+}
+
+// MarkTokenConsumed 標記於第一層，與其他寫入相同不降級
+func (s *FallbackStore) MarkTokenConsumed(ctx context.Context, tokenID string, ttl time.Duration) (bool, error) {
+	onceStore, ok := s.tiers[0].Store.(OnceTokenStore)
+	if !ok {
+		return false, ErrOnceTokenUnsupported
+	}
+	return onceStore.MarkTokenConsumed(ctx, tokenID, ttl)
+}
+
+// SetUserStatus 寫入第一層
+func (s *FallbackStore) SetUserStatus(ctx context.Context, userID string, status UserStatus) error {
+	return s.tiers[0].Store.SetUserStatus(ctx, userID, status)
+}
+
+// SetForceLogout 寫入第一層
+func (s *FallbackStore) SetForceLogout(ctx context.Context, userID string, timestamp int64) error {
+	return s.tiers[0].Store.SetForceLogout(ctx, userID, timestamp)
+}
+
+// ClearForceLogout 寫入第一層
+func (s *FallbackStore) ClearForceLogout(ctx context.Context, userID string) error {
+	return s.tiers[0].Store.ClearForceLogout(ctx, userID)
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tierStub 可設定回應的 PermissionStore，未實作 BatchPermissionStore
+type tierStub struct {
+	err         error
+	block       bool // 阻塞至 ctx 結束，模擬無回應的後端
+	status      *UserStatus
+	permissions map[string][]string
+	calls       atomic.Int64
+}
+
+func (s *tierStub) respond(ctx context.Context) error {
+	s.calls.Add(1)
+	if s.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.err
+}
+
+func (s *tierStub) GetUserStatus(ctx context.Context, _ string) (*UserStatus, error) {
+	if err := s.respond(ctx); err != nil {
+		return nil, err
+	}
+	if s.status == nil {
+		return nil, ErrCacheNotFound
+	}
+	return s.status, nil
+}
+
+func (s *tierStub) SetUserStatus(context.Context, string, UserStatus) error { return nil }
+
+func (s *tierStub) GetForceLogout(ctx context.Context, _ string) (int64, error) {
+	if err := s.respond(ctx); err != nil {
+		return 0, err
+	}
+	return 0, ErrCacheNotFound
+}
+
+func (s *tierStub) SetForceLogout(context.Context, string, int64) error { return nil }
+
+func (s *tierStub) ClearForceLogout(context.Context, string) error { return nil }
+
+func (s *tierStub) GetDynamicPermissions(ctx context.Context, userID string) ([]string, error) {
+	if err := s.respond(ctx); err != nil {
+		return nil, err
+	}
+	permissions, ok := s.permissions[userID]
+	if !ok {
+		return nil, ErrCacheNotFound
+	}
+	return permissions, nil
+}
+
+func TestFallbackStoreTierTimeout(t *testing.T) {
+	hung := &tierStub{block: true}
+	backup := &tierStub{status: &UserStatus{IsActive: true}}
+	store, err := NewFallbackStore(zap.NewNop(),
+		StoreTier{Name: "hung", Store: hung, Timeout: 20 * time.Millisecond},
+		StoreTier{Name: "backup", Store: backup},
+	)
+	if err != nil {
+		t.Fatalf("NewFallbackStore: %v", err)
+	}
+
+	start := time.Now()
+	status, err := store.GetUserStatus(context.Background(), "42")
+	if err != nil || status == nil || !status.IsActive {
+		t.Fatalf("GetUserStatus = %+v, %v; want active status from backup", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetUserStatus took %v despite a 20ms tier timeout", elapsed)
+	}
+}
+
+func TestFallbackStoreCooldown(t *testing.T) {
+	down := &tierStub{err: errors.New("connection refused")}
+	backup := &tierStub{}
+	store, err := NewFallbackStore(zap.NewNop(),
+		StoreTier{Name: "down", Store: down, Cooldown: time.Minute},
+		StoreTier{Name: "backup", Store: backup},
+	)
+	if err != nil {
+		t.Fatalf("NewFallbackStore: %v", err)
+	}
+
+	// 一次驗證的三項檢查：第一次失敗後，其餘檢查直接略過中斷的層級
+	ctx := context.Background()
+	_, _ = store.GetUserStatus(ctx, "42")
+	_, _ = store.GetForceLogout(ctx, "42")
+	_, _ = store.GetDynamicPermissions(ctx, "42")
+
+	if got := down.calls.Load(); got != 1 {
+		t.Errorf("calls to failed tier = %d, want 1", got)
+	}
+	if got := backup.calls.Load(); got != 3 {
+		t.Errorf("calls to backup tier = %d, want 3", got)
+	}
+	stats := store.Stats()
+	if stats["down"] != (TierStats{Failures: 1, Skipped: 2}) {
+		t.Errorf("down stats = %+v, want 1 failure and 2 skipped", stats["down"])
+	}
+	if stats["backup"] != (TierStats{Served: 3}) {
+		t.Errorf("backup stats = %+v, want 3 served", stats["backup"])
+	}
+}
+
+func TestFallbackStoreCorruptCacheDoesNotCoolDown(t *testing.T) {
+	corrupt := &tierStub{err: ErrCorruptCache}
+	backup := &tierStub{}
+	store, err := NewFallbackStore(zap.NewNop(),
+		StoreTier{Name: "corrupt", Store: corrupt, Cooldown: time.Minute},
+		StoreTier{Name: "backup", Store: backup},
+	)
+	if err != nil {
+		t.Fatalf("NewFallbackStore: %v", err)
+	}
+
+	_, _ = store.GetDynamicPermissions(context.Background(), "1")
+	_, _ = store.GetDynamicPermissions(context.Background(), "2")
+
+	if got := corrupt.calls.Load(); got != 2 {
+		t.Errorf("calls to tier with corrupt entry = %d, want 2", got)
+	}
+}
+
+func TestFallbackStoreAllTiersFail(t *testing.T) {
+	store, err := NewFallbackStore(zap.NewNop(),
+		StoreTier{Name: "a", Store: &tierStub{err: errors.New("a down")}},
+		StoreTier{Name: "b", Store: &tierStub{err: errors.New("b down")}},
+	)
+	if err != nil {
+		t.Fatalf("NewFallbackStore: %v", err)
+	}
+
+	_, err = store.GetForceLogout(context.Background(), "42")
+	if err == nil || err.Error() != "b: b down" {
+		t.Fatalf("err = %v, want the last tier's error", err)
+	}
+}
+
+func TestFallbackStoreBatch(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:dynamic_permissions:1", `["user:read"]`)
+	redisStore := NewRedisStore(redisClient, RedisStoreConfig{Logger: zap.NewNop()})
+	backup := &tierStub{permissions: map[string][]string{"1": {"backup:read"}, "3": {"order:read"}}}
+
+	store, err := NewFallbackStore(zap.NewNop(),
+		StoreTier{Name: "redis", Store: redisStore, Cooldown: time.Minute},
+		StoreTier{Name: "backup", Store: backup},
+	)
+	if err != nil {
+		t.Fatalf("NewFallbackStore: %v", err)
+	}
+	var _ BatchPermissionStore = store
+
+	// Redis 正常：全部由第一層的 pipeline 回應，不支援批次的下一層不會被查詢
+	got, err := store.GetDynamicPermissionsBatch(context.Background(), []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("GetDynamicPermissionsBatch: %v", err)
+	}
+	if len(got) != 2 || len(got["1"]) != 1 || got["1"][0] != "user:read" || got["2"] != nil {
+		t.Errorf("result = %v, want user 1 from redis and nil for user 2", got)
+	}
+	if calls := backup.calls.Load(); calls != 0 {
+		t.Errorf("backup tier called %d times while redis was healthy", calls)
+	}
+
+	// Redis 中斷：整批改由下一層逐一查詢
+	server.Close()
+	got, err = store.GetDynamicPermissionsBatch(context.Background(), []string{"1", "2", "3"})
+	if err != nil {
+		t.Fatalf("GetDynamicPermissionsBatch with redis down: %v", err)
+	}
+	if fmt.Sprint(got) != "map[1:[backup:read] 2:[] 3:[order:read]]" || got["2"] != nil {
+		t.Errorf("result = %v, want every user from the backup tier", got)
+	}
+	if stats := store.Stats()["redis"]; stats.Failures != 1 || stats.Served != 2 {
+		t.Errorf("redis stats = %+v, want 2 served and 1 failure", stats)
+	}
+
+	// 冷卻期間不再嘗試 Redis
+	if _, err := store.GetUserStatuses(context.Background(), []string{"1"}); err != nil {
+		t.Fatalf("GetUserStatuses during cooldown: %v", err)
+	}
+	if stats := store.Stats()["redis"]; stats.Skipped != 1 {
+		t.Errorf("redis stats = %+v, want 1 skipped", stats)
+	}
+}
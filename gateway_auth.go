@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TrustedGatewayConfig 信任上游閘道轉送身份的設定
+type TrustedGatewayConfig struct {
+	// SharedSecret 閘道與服務之間的共用密鑰，請求須於 SecretHeader 帶上相同值
+	SharedSecret string
+	// SecretHeader 密鑰標頭名稱，預設 X-Gateway-Secret
+	SecretHeader string
+	// RequireMTLS 要求請求經由已驗證用戶端憑證的 TLS 連線抵達（服務直接終結 mTLS 時使用）
+	RequireMTLS bool
+
+	// 身份標頭名稱，預設分別為 X-User-ID、X-User-Name、X-User-Email、X-User-Roles、X-User-Permissions
+	// 角色與權限以逗號分隔
+	UserIDHeader      string
+	UsernameHeader    string
+	EmailHeader       string
+	RolesHeader       string
+	PermissionsHeader string
+
+	// CheckUserStatus 是否仍查詢用戶狀態與強制登出標記，讓停用或被強制登出的用戶在閘道 token 到期前即被拒絕
+	// authClient 為 *Client 時沿用其 FailClosed：無法確認時回應 503，而非預設放行
+	CheckUserStatus bool
+	// IssuedAtHeader 閘道轉送原始 token 簽發時間（Unix 秒）的標頭，用於強制登出比較，預設 X-Token-Issued-At
+	// 缺少此標頭時的處理與 token 缺少 iat 相同（見 Config.SkipForceLogoutWithoutIssuedAt）
+	IssuedAtHeader string
+}
+
+// TrustedGatewayAuth 以上游閘道轉送的標頭重建身份上下文，取代 JWT 驗證
+//
+// 信任邊界：身份標頭可由任何能連到服務的用戶端偽造，此中介軟體只在請求能證明來自閘道時才採信，
+// 因此必須設定 SharedSecret 或 RequireMTLS（兩者皆未設定時 panic）。閘道須移除外部請求自帶的同名標頭，
+// 且服務不可對外暴露繞過閘道的入口；密鑰外洩等同任何人都能冒充任意用戶
+// 設置的上下文與 Authenticate 相同，可接續使用 RequirePermission 等授權中介軟體
+func (m *GinMiddleware) TrustedGatewayAuth(config TrustedGatewayConfig) gin.HandlerFunc {
+	if config.SharedSecret == "" && !config.RequireMTLS {
+		panic("auth: TrustedGatewayAuth requires SharedSecret or RequireMTLS")
+	}
+	if config.SecretHeader == "" {
+		config.SecretHeader = "X-Gateway-Secret"
+	}
+	if config.UserIDHeader == "" {
+		config.UserIDHeader = "X-User-ID"
+	}
+	if config.UsernameHeader == "" {
+		config.UsernameHeader = "X-User-Name"
+	}
+	if config.EmailHeader == "" {
+		config.EmailHeader = "X-User-Email"
+	}
+	if config.RolesHeader == "" {
+		config.RolesHeader = "X-User-Roles"
+	}
+	if config.PermissionsHeader == "" {
+		config.PermissionsHeader = "X-User-Permissions"
+	}
+	if config.IssuedAtHeader == "" {
+		config.IssuedAtHeader = "X-Token-Issued-At"
+	}
+	failClosed := false
+	if client, ok := m.authClient.(*Client); ok {
+		failClosed = client.config.FailClosed
+	}
+	secret := []byte(config.SharedSecret)
+
+	return func(c *gin.Context) {
+		if config.RequireMTLS {
+			if tls := c.Request.TLS; tls == nil || len(tls.VerifiedChains) == 0 {
+				m.logger.Warn("Gateway request without verified client certificate",
+					zap.String("client_ip", m.IPResolver.ClientIP(c)))
+				m.respondUnauthorized(c, "Untrusted gateway")
+				return
+			}
+		}
+		if len(secret) > 0 && subtle.ConstantTimeCompare([]byte(c.GetHeader(config.SecretHeader)), secret) != 1 {
+			m.logger.Warn("Gateway request with invalid shared secret",
+				zap.String("client_ip", m.IPResolver.ClientIP(c)))
+			m.respondUnauthorized(c, "Untrusted gateway")
+			return
+		}
+
+		userID := strings.TrimSpace(c.GetHeader(config.UserIDHeader))
+		if userID == "" {
+			m.respondUnauthorized(c, "Missing gateway identity")
+			return
+		}
+
+		claims := &Claims{
+			UserID:      userID,
+			Username:    c.GetHeader(config.UsernameHeader),
+			Email:       c.GetHeader(config.EmailHeader),
+			Roles:       splitHeaderList(c.GetHeader(config.RolesHeader)),
+			Permissions: splitHeaderList(c.GetHeader(config.PermissionsHeader)),
+		}
+
+		if config.CheckUserStatus && !m.checkGatewayUser(c, userID, config.IssuedAtHeader, failClosed) {
+			return
+		}
+
+		setUserContext(c, &AuthResult{
+			Claims:             claims,
+			IsActive:           true,
+			DynamicPermissions: claims.Permissions,
+		})
+		m.setAuthenticatedLogger(c, claims)
+
+		c.Next()
+	}
+}
+
+// checkGatewayUser 查詢閘道轉送用戶的狀態與強制登出標記，拒絕請求時回傳 false
+// 與 ValidateTokenWithDynamicAuth 相同：failClosed 時無法確認即回應 503，否則記錄警告後放行
+func (m *GinMiddleware) checkGatewayUser(c *gin.Context, userID, issuedAtHeader string, failClosed bool) bool {
+	ctx := c.Request.Context()
+
+	isActive, err := m.authClient.CheckUserStatus(ctx, userID)
+	if err != nil {
+		if failClosed {
+			m.logger.Warn("Failed to check user status, rejecting gateway request (fail closed)",
+				zap.String("user_id", userID), zap.Error(err))
+			m.recordFailure(c, FailureReasonUnavailable, "", userID)
+			m.respondServiceUnavailable(c, "Authentication service is unavailable, please retry")
+			return false
+		}
+		m.logger.Warn("Failed to check user status, defaulting to active",
+			zap.String("user_id", userID), zap.Error(err))
+	} else if !isActive {
+		m.recordFailure(c, FailureReasonUserDisabled, "", userID)
+		m.respondForbidden(c, "User account is disabled")
+		return false
+	}
+
+	issuedAt, _ := strconv.ParseInt(strings.TrimSpace(c.GetHeader(issuedAtHeader)), 10, 64)
+	forced, err := m.authClient.CheckForceLogout(ctx, userID, issuedAt)
+	if err != nil && failClosed {
+		if !errors.Is(err, ErrMissingIssuedAt) {
+			m.logger.Warn("Failed to check force logout, rejecting gateway request (fail closed)",
+				zap.String("user_id", userID), zap.Error(err))
+			m.recordFailure(c, FailureReasonUnavailable, "", userID)
+			m.respondServiceUnavailable(c, "Authentication service is unavailable, please retry")
+			return false
+		}
+		// 存在強制登出標記但無法證明 token 簽發於其後，要求重新登入
+		forced, err = true, nil
+	}
+	if err != nil {
+		m.logger.Warn("Failed to check force logout, defaulting to false",
+			zap.String("user_id", userID), zap.Error(err))
+		forced = false
+	}
+	if forced {
+		m.recordFailure(c, FailureReasonForceLogout, "", userID)
+		m.runForceLogoutHook(c, userID)
+		m.respondUnauthorized(c, "Please login again")
+		return false
+	}
+	return true
+}
+
+// splitHeaderList 解析逗號分隔的標頭值，忽略空白項目
+func splitHeaderList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
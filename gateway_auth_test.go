@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTrustedGatewayAuthChecksUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		marker     string
+		issuedAt   string
+		redisDown  bool
+		failClosed bool
+		wantCode   int
+	}{
+		{name: "active", wantCode: http.StatusOK},
+		{name: "disabled", status: `{"is_active": false}`, wantCode: http.StatusForbidden},
+		{name: "forced before issued", marker: "1000", issuedAt: "2000", wantCode: http.StatusOK},
+		{name: "forced after issued", marker: "2000", issuedAt: "1000", wantCode: http.StatusUnauthorized},
+		{name: "forced without issued-at fails open", marker: "2000", wantCode: http.StatusOK},
+		{name: "forced without issued-at fails closed", marker: "2000", failClosed: true, wantCode: http.StatusUnauthorized},
+		{name: "store down fails open", redisDown: true, wantCode: http.StatusOK},
+		{name: "store down fails closed", redisDown: true, failClosed: true, wantCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, redisClient := newTestRedis(t)
+			if tt.status != "" {
+				server.Set("user:status:42", tt.status)
+			}
+			if tt.marker != "" {
+				server.Set("user:force_logout:42", tt.marker)
+			}
+			client := newTestClient(t, &Config{
+				PublicKeyPath: mustRSAKeyPath(t),
+				RedisClient:   redisClient,
+				FailClosed:    tt.failClosed,
+			})
+			if tt.redisDown {
+				server.SetError("connection refused")
+			}
+
+			forceLogoutHook := 0
+			m := NewGinMiddleware(client, zap.NewNop())
+			m.OnForceLogout = func(_ context.Context, _ string) { forceLogoutHook++ }
+
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+			req.Header.Set("X-Gateway-Secret", "s3cret")
+			req.Header.Set("X-User-ID", "42")
+			if tt.issuedAt != "" {
+				req.Header.Set("X-Token-Issued-At", tt.issuedAt)
+			}
+
+			w, reached := serve(req, m.TrustedGatewayAuth(TrustedGatewayConfig{SharedSecret: "s3cret", CheckUserStatus: true}))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if reached != (tt.wantCode == http.StatusOK) {
+				t.Errorf("handler reached = %v", reached)
+			}
+			if wantHook := tt.wantCode == http.StatusUnauthorized; (forceLogoutHook == 1) != wantHook {
+				t.Errorf("OnForceLogout calls = %d, want hook %v", forceLogoutHook, wantHook)
+			}
+		})
+	}
+}
+
+func TestTrustedGatewayAuthCustomIssuedAtHeader(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:force_logout:42", "1000")
+	client := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t), RedisClient: redisClient})
+	m := NewGinMiddleware(client, zap.NewNop())
+	handler := m.TrustedGatewayAuth(TrustedGatewayConfig{
+		SharedSecret:    "s3cret",
+		CheckUserStatus: true,
+		IssuedAtHeader:  "X-Iat",
+	})
+
+	for _, iat := range []int64{999, 1000, 1001} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("X-Gateway-Secret", "s3cret")
+		req.Header.Set("X-User-ID", "42")
+		req.Header.Set("X-Iat", strconv.FormatInt(iat, 10))
+
+		want := http.StatusUnauthorized
+		if iat > 1000 {
+			want = http.StatusOK
+		}
+		if w, _ := serve(req, handler); w.Code != want {
+			t.Errorf("iat %d: status = %d, want %d", iat, w.Code, want)
+		}
+	}
+}
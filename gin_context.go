@@ -0,0 +1,94 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// Authenticate 與 OptionalAuth 寫入 gin.Context 的 key，下游 handler 可直接以 c.Get 讀取
+const (
+	ContextKeyUserID      = "user_id"
+	ContextKeyUsername    = "username"
+	ContextKeyEmail       = "email"
+	ContextKeyRoles       = "roles"
+	ContextKeyPermissions = "permissions" // 動態權限
+	ContextKeyTokenID     = "token_id"
+	ContextKeyTokenTenant = "token_tenant_id" // token 的 tenant_id 聲明
+
+	// ContextKeyRequestID RequestID 中介軟體設置的請求 ID
+	ContextKeyRequestID = "request_id"
+
+	// ContextKeyValidatedFromCache Authenticate 與 OptionalAuth 設置，驗證結果是否來自驗證快取（見 AuthResult.ValidatedFromCache）
+	ContextKeyValidatedFromCache = "auth_validated_from_cache"
+
+	// ContextKeyDryRunDenied DryRun 模式下，請求原本會被權限檢查拒絕時設為 true
+	ContextKeyDryRunDenied = "auth_dry_run_denied"
+)
+
+// GetUserID 取得 Authenticate 設置的用戶 ID，未驗證或型別不符時回傳 false
+func GetUserID(c *gin.Context) (string, bool) {
+	return contextString(c, ContextKeyUserID)
+}
+
+// MustGetUserID 取得用戶 ID，缺少時 panic；僅用於已掛載 Authenticate 的路由
+func MustGetUserID(c *gin.Context) string {
+	userID, ok := GetUserID(c)
+	if !ok {
+		panic("auth: user_id not found in context, is Authenticate mounted on this route?")
+	}
+	return userID
+}
+
+// GetUsername 取得用戶名稱
+func GetUsername(c *gin.Context) (string, bool) {
+	return contextString(c, ContextKeyUsername)
+}
+
+// GetEmail 取得用戶信箱
+func GetEmail(c *gin.Context) (string, bool) {
+	return contextString(c, ContextKeyEmail)
+}
+
+// GetTokenID 取得 token ID（jti）
+func GetTokenID(c *gin.Context) (string, bool) {
+	return contextString(c, ContextKeyTokenID)
+}
+
+// GetRoles 取得用戶角色
+func GetRoles(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(ContextKeyRoles)
+	if !exists {
+		return nil, false
+	}
+	roles, ok := value.([]string)
+	return roles, ok
+}
+
+// GetPermissions 取得用戶權限（Authenticate 設置的動態權限）
+func GetPermissions(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(ContextKeyPermissions)
+	if !exists {
+		return nil, false
+	}
+	permissions, ok := value.([]string)
+	return permissions, ok
+}
+
+// setUserContext 將驗證結果寫入上下文（使用動態權限）
+func setUserContext(c *gin.Context, result *AuthResult) {
+	claims := result.Claims
+	c.Set(ContextKeyUserID, claims.UserID)
+	c.Set(ContextKeyUsername, claims.Username)
+	c.Set(ContextKeyEmail, claims.Email)
+	c.Set(ContextKeyRoles, claims.Roles)
+	c.Set(ContextKeyPermissions, result.DynamicPermissions)
+	c.Set(ContextKeyTokenID, claims.ID)
+	c.Set(ContextKeyTokenTenant, claims.TenantID)
+}
+
+// contextString 讀取字串型別的上下文值
+func contextString(c *gin.Context, key string) (string, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return "", false
+	}
+	s, ok := value.(string)
+	return s, ok
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+func TestAuthenticateSetsContextKeys(t *testing.T) {
+	result := &AuthResult{
+		Claims: &Claims{
+			UserID:           "42",
+			Username:         "alice",
+			Email:            "alice@example.com",
+			Roles:            []string{"admin"},
+			Permissions:      []string{"jwt:only"},
+			TenantID:         "acme",
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1"},
+		},
+		DynamicPermissions: []string{"user:read"},
+		IsActive:           true,
+		ValidatedFromCache: true,
+	}
+	m := NewGinMiddleware(&stubAuthClient{results: map[string]*AuthResult{"token": result}}, zap.NewNop())
+
+	var got map[string]interface{}
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w, reached := serve(req, m.Authenticate(), func(c *gin.Context) {
+		got = c.Copy().Keys
+	})
+	if w.Code != http.StatusOK || !reached {
+		t.Fatalf("status = %d, reached = %v", w.Code, reached)
+	}
+
+	want := map[string]interface{}{
+		ContextKeyUserID:             "42",
+		ContextKeyUsername:           "alice",
+		ContextKeyEmail:              "alice@example.com",
+		ContextKeyTokenID:            "jti-1",
+		ContextKeyTokenTenant:        "acme",
+		ContextKeyValidatedFromCache: true,
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("c.Get(%q) = %#v, want %#v", key, got[key], value)
+		}
+	}
+	if roles, _ := got[ContextKeyRoles].([]string); !slices.Equal(roles, []string{"admin"}) {
+		t.Errorf("c.Get(%q) = %#v, want [admin]", ContextKeyRoles, got[ContextKeyRoles])
+	}
+	// 權限為動態權限，而非 token 內的權限
+	if permissions, _ := got[ContextKeyPermissions].([]string); !slices.Equal(permissions, []string{"user:read"}) {
+		t.Errorf("c.Get(%q) = %#v, want [user:read]", ContextKeyPermissions, got[ContextKeyPermissions])
+	}
+}
+
+func TestContextGetters(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	setUserContext(c, activeResult("42", "user:read"))
+
+	if userID, ok := GetUserID(c); !ok || userID != "42" {
+		t.Errorf("GetUserID = %q, %v", userID, ok)
+	}
+	if permissions, ok := GetPermissions(c); !ok || !slices.Equal(permissions, []string{"user:read"}) {
+		t.Errorf("GetPermissions = %v, %v", permissions, ok)
+	}
+	if MustGetUserID(c) != "42" {
+		t.Errorf("MustGetUserID = %q", MustGetUserID(c))
+	}
+
+	empty, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if _, ok := GetUserID(empty); ok {
+		t.Error("GetUserID on an unauthenticated context returned ok")
+	}
+	if _, ok := GetRoles(empty); ok {
+		t.Error("GetRoles on an unauthenticated context returned ok")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGetUserID did not panic without Authenticate")
+		}
+	}()
+	MustGetUserID(empty)
+}
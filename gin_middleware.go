@@ -3,9 +3,14 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -14,78 +19,170 @@ import (
 type GinMiddleware struct {
 	authClient AuthClient
 	logger     *zap.Logger
+	registry   *permissionRegistry // 授權檢查引用的權限與角色，WithDryRun 的副本共用
+
+	// AutoRefresh 啟用時，Authenticate 會自動刷新即將過期或已過期的 token（nil 表示停用）
+	// authClient 須實作 TokenRefresher
+	AutoRefresh *AutoRefreshConfig
+
+	// FailureSink 設定後，Authenticate 的每次驗證失敗都會輸出為分析事件（nil 表示停用）
+	FailureSink *FailureSink
+
+	// OnAuthSuccess Authenticate 驗證成功後非同步呼叫，用於更新最後上線時間、登入統計等副作用
+	// ctx 不會隨請求結束而取消；hook 的 panic 會被攔截並記錄
+	OnAuthSuccess func(ctx context.Context, result *AuthResult)
+
+	// OnForceLogout Authenticate 因強制登出拒絕請求時、回應 401 前同步呼叫，用於關閉 SSE 連線、清除伺服器端 session 等清理工作
+	// ctx 不會隨請求結束而取消，但最多等待 ForceLogoutHookTimeout，逾時後不再等待並照常回應；hook 的 panic 會被攔截並記錄
+	OnForceLogout func(ctx context.Context, userID string)
+	// ForceLogoutHookTimeout OnForceLogout 的等待上限，預設 5 秒
+	ForceLogoutHookTimeout time.Duration
+
+	// IPResolver 解析失敗事件中的用戶端 IP；authClient 為 *Client 時預設沿用其 TrustedProxies 設定
+	IPResolver *ClientIPResolver
+
+	// TokenExtractor Authenticate 與 OptionalAuth 未傳入擷取器時使用的預設擷取器
+	// 為 nil 時讀取 Authorization: Bearer 標頭（啟用 AutoRefresh 時另讀取 access token Cookie）
+	// 可用 ChainExtractors 組合多個來源，如 ChainExtractors(BearerExtractor(), CookieExtractor{Name: "access_token"}, QueryExtractor{Name: "token"})
+	TokenExtractor TokenExtractor
+
+	// OnPermissionDenied 權限或角色檢查拒絕請求時、寫入 403 回應前同步呼叫，用於稽核事件與指標
+	// required 為該檢查要求的權限（角色條件以 role: 前綴表示，RequireExpr 為整條表達式）；hook 的 panic 會被攔截並記錄
+	OnPermissionDenied func(c *gin.Context, required []string)
+
+	// DryRun 權限與角色檢查只記錄、不阻擋：原本會回應 403 的請求改為記錄警告、呼叫 OnPermissionDenied 後繼續處理
+	// 用於上線新的權限要求前觀察受影響的用戶；hook 可透過 ContextKeyDryRunDenied 區分
+	// 不影響 Authenticate 的身份驗證與帳號狀態檢查
+	DryRun bool
+}
+
+// AutoRefreshConfig 自動刷新 token 設定（適用於以 Cookie 保存 token 的伺服器渲染應用）
+type AutoRefreshConfig struct {
+	RefreshCookieName string        // 存放 refresh token 的 Cookie 名稱
+	AccessCookieName  string        // 存放 access token 的 Cookie 名稱（同時作為 header 缺失時的來源）
+	Threshold         time.Duration // access token 剩餘有效時間低於此值時自動刷新
+	CookiePath        string        // Cookie 路徑，預設為 "/"
+	CookieDomain      string        // Cookie 網域
+	Secure            bool          // 是否僅透過 HTTPS 傳送 Cookie
 }
 
 // NewGinMiddleware 建立新的 Gin 中介軟體
 func NewGinMiddleware(authClient AuthClient, logger *zap.Logger) *GinMiddleware {
-	return &GinMiddleware{
+	m := &GinMiddleware{
 		authClient: authClient,
 		logger:     logger,
+		registry:   newPermissionRegistry(),
+	}
+	if client, ok := authClient.(*Client); ok {
+		m.IPResolver = client.IPResolver()
 	}
+	return m
 }
 
 // ErrorResponse 統一錯誤回應格式
 type ErrorResponse struct {
-	Success bool   `json:"success"`
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Error   string `json:"error"`
+	Success bool        `json:"success"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"` // 結構化的錯誤細節，如 PermissionDeniedDetails
+}
+
+// PermissionDeniedDetails 權限不足時 ErrorResponse.Details 的內容，讓用戶端依缺少的權限顯示對應訊息
+// 角色與表達式檢查以描述字串表示，如 role:admin
+type PermissionDeniedDetails struct {
+	RequiredPermission  string   `json:"required_permission,omitempty"`  // 要求單一權限時
+	RequiredPermissions []string `json:"required_permissions,omitempty"` // 要求其中任一時
 }
 
 // Authenticate 身份驗證中介軟體（使用動態權限檢查）
-func (m *GinMiddleware) Authenticate() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 1. 獲取 Authorization 標頭
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			m.respondUnauthorized(c, "Missing authorization header")
-			c.Abort()
-			return
-		}
+// 可傳入 token 擷取器並依序嘗試；未指定時讀取 Authorization: Bearer 標頭
+func (m *GinMiddleware) Authenticate(extractors ...TokenExtractor) gin.HandlerFunc {
+	if len(extractors) == 0 {
+		extractors = m.defaultExtractors()
+	}
 
-		// 2. 檢查 Bearer 格式
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			m.respondUnauthorized(c, "Invalid authorization header format")
-			c.Abort()
+	return func(c *gin.Context) {
+		// 1. 依序透過擷取器取得 token
+		tokenString, ok := extractToken(c, extractors)
+		if !ok {
+			// 2. 區分缺少標頭與標頭格式錯誤
+			if c.GetHeader("Authorization") != "" {
+				m.recordFailure(c, FailureReasonInvalidFormat, "", "")
+				m.respondUnauthorized(c, "Invalid authorization header format")
+			} else {
+				m.recordFailure(c, FailureReasonMissingToken, "", "")
+				m.respondUnauthorized(c, "Missing authorization header")
+			}
 			return
 		}
 
 		// 3. 執行完整的動態身份驗證
-		authResult, err := m.authClient.ValidateTokenWithDynamicAuth(c.Request.Context(), tokenString)
+		authResult, err := m.authClient.ValidateTokenWithDynamicAuth(requestContext(c), tokenString)
+		if err != nil && m.AutoRefresh != nil && errors.Is(err, ErrTokenExpired) {
+			// token 已過期：嘗試以 refresh token 換發，若 refresh token 也失效則回傳 401
+			authResult, err = m.refreshAndValidate(c)
+		}
 		if err != nil {
-			m.logger.Debug("Token validation failed", 
+			m.logger.Debug("Token validation failed",
 				zap.Error(err),
 				zap.String("token_prefix", tokenString[:min(len(tokenString), 20)]))
-			m.respondUnauthorized(c, "Invalid or expired token")
-			c.Abort()
+			if errors.Is(err, ErrValidationOverloaded) {
+				m.recordFailure(c, FailureReasonOverloaded, tokenString, "")
+				m.respondServiceUnavailable(c, "Authentication service is busy, please retry")
+				return
+			}
+			if errors.Is(err, ErrDynamicAuthUnavailable) {
+				m.logger.Warn("Dynamic auth unavailable, rejecting request (fail closed)", zap.Error(err))
+				m.recordFailure(c, FailureReasonUnavailable, tokenString, "")
+				m.respondServiceUnavailable(c, "Authentication service is unavailable, please retry")
+				return
+			}
+			if errors.Is(err, ErrTokenExpired) {
+				m.recordFailure(c, FailureReasonTokenExpired, tokenString, "")
+				m.respondUnauthorized(c, "Token has expired")
+				return
+			}
+			m.recordFailure(c, FailureReasonInvalidToken, tokenString, "")
+			m.respondUnauthorized(c, "Invalid token")
 			return
 		}
 
 		// 4. 檢查用戶是否啟用
 		if !authResult.IsActive {
+			m.recordFailure(c, FailureReasonUserDisabled, tokenString, authResult.Claims.UserID)
 			m.respondForbidden(c, "User account is disabled")
-			c.Abort()
 			return
 		}
 
 		// 5. 檢查是否需要強制登出
 		if authResult.ShouldForceLogout {
+			m.recordFailure(c, FailureReasonForceLogout, tokenString, authResult.Claims.UserID)
+			m.runForceLogoutHook(c, authResult.Claims.UserID)
 			m.respondUnauthorized(c, "Please login again")
-			c.Abort()
 			return
 		}
 
 		// 6. 設置用戶上下文（使用動態權限）
 		claims := authResult.Claims
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("roles", claims.Roles)
-		c.Set("permissions", authResult.DynamicPermissions) // 使用動態權限
-		c.Set("token_id", claims.ID)
-
-		// 7. 記錄成功驗證
+		setUserContext(c, authResult)
+		c.Set(ContextKeyValidatedFromCache, authResult.ValidatedFromCache)
+		m.setAuthenticatedLogger(c, claims)
+
+		// 7. token 即將過期時自動刷新（失敗不影響本次請求）
+		if m.AutoRefresh != nil && m.shouldRefresh(claims) {
+			if _, err := m.refreshFromCookie(c); err != nil {
+				m.logger.Debug("Token auto refresh failed",
+					zap.String("user_id", claims.UserID), zap.Error(err))
+			}
+		}
+
+		// 8. 非同步執行驗證成功 hook，不增加請求延遲；傳入副本避免 hook 與 handler 共用權限、角色切片
+		if m.OnAuthSuccess != nil {
+			go m.runAuthSuccessHook(context.WithoutCancel(c.Request.Context()), cloneAuthResult(authResult))
+		}
+
+		// 9. 記錄成功驗證
 		m.logger.Debug("User authenticated successfully",
 			zap.String("user_id", claims.UserID),
 			zap.String("username", claims.Username),
@@ -97,18 +194,18 @@ func (m *GinMiddleware) Authenticate() gin.HandlerFunc {
 
 // RequirePermission 需要特定權限的中介軟體
 func (m *GinMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	m.registry.addPermissions(permission)
+
 	return func(c *gin.Context) {
-		permissions, exists := c.Get("permissions")
+		permissions, exists := c.Get(ContextKeyPermissions)
 		if !exists {
-			m.respondForbidden(c, "No permissions found")
-			c.Abort()
+			m.denyPermission(c, []string{permission}, "No permissions found")
 			return
 		}
 
 		userPermissions, ok := permissions.([]string)
 		if !ok {
-			m.respondForbidden(c, "Invalid permissions format")
-			c.Abort()
+			m.denyPermission(c, []string{permission}, "Invalid permissions format")
 			return
 		}
 
@@ -118,10 +215,10 @@ func (m *GinMiddleware) RequirePermission(permission string) gin.HandlerFunc {
 			m.logger.Info("Permission denied",
 				zap.String("user_id", m.getUserID(c)),
 				zap.String("required_permission", permission),
-				zap.Strings("user_permissions", userPermissions))
-			
-			m.respondForbidden(c, "Insufficient permissions: required '"+permission+"'")
-			c.Abort()
+				zap.Strings("user_permissions", userPermissions),
+				zap.Any("near_misses", nearMisses(userPermissions, []string{permission})))
+
+			m.denyPermission(c, []string{permission}, "Insufficient permissions: required '"+permission+"'")
 			return
 		}
 
@@ -129,20 +226,35 @@ func (m *GinMiddleware) RequirePermission(permission string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermissionValue 同 RequirePermission，接受經 ParsePermission 驗證的權限值
+func (m *GinMiddleware) RequirePermissionValue(permission Permission) gin.HandlerFunc {
+	return m.RequirePermission(permission.String())
+}
+
 // RequireAnyPermission 需要任一權限的中介軟體
 func (m *GinMiddleware) RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	m.registry.addPermissions(permissions...)
+
 	return func(c *gin.Context) {
-		userPermissions, exists := c.Get("permissions")
+		userPermissions, exists := c.Get(ContextKeyPermissions)
 		if !exists {
-			m.respondForbidden(c, "No permissions found")
-			c.Abort()
+			m.denyPermission(c, permissions, "No permissions found")
 			return
 		}
 
 		userPerms, ok := userPermissions.([]string)
 		if !ok {
-			m.respondForbidden(c, "Invalid permissions format")
-			c.Abort()
+			m.denyPermission(c, permissions, "Invalid permissions format")
+			return
+		}
+
+		// 用戶沒有任何權限（nil 或空切片），與缺少特定權限分開提示
+		if len(userPerms) == 0 {
+			m.logger.Info("Permission denied: user has no permissions",
+				zap.String("user_id", m.getUserID(c)),
+				zap.Strings("required_permissions", permissions))
+
+			m.denyPermission(c, permissions, "Insufficient permissions: user has no permissions, required one of ["+strings.Join(permissions, ", ")+"]")
 			return
 		}
 
@@ -159,10 +271,10 @@ func (m *GinMiddleware) RequireAnyPermission(permissions ...string) gin.HandlerF
 			m.logger.Info("Permission denied",
 				zap.String("user_id", m.getUserID(c)),
 				zap.Strings("required_permissions", permissions),
-				zap.Strings("user_permissions", userPerms))
-			
-			m.respondForbidden(c, "Insufficient permissions: required one of ["+strings.Join(permissions, ", ")+"]")
-			c.Abort()
+				zap.Strings("user_permissions", userPerms),
+				zap.Any("near_misses", nearMisses(userPerms, permissions)))
+
+			m.denyPermission(c, permissions, "Insufficient permissions: required one of ["+strings.Join(permissions, ", ")+"]")
 			return
 		}
 
@@ -170,55 +282,293 @@ func (m *GinMiddleware) RequireAnyPermission(permissions ...string) gin.HandlerF
 	}
 }
 
-// OptionalAuth 可選身份驗證（如果有 token 則驗證，但不強制要求）
-func (m *GinMiddleware) OptionalAuth() gin.HandlerFunc {
+// RequireRole 需要特定角色的中介軟體
+func (m *GinMiddleware) RequireRole(role string) gin.HandlerFunc {
+	return m.RequireAnyRole(role)
+}
+
+// RequireAnyRole 需要任一角色的中介軟體
+// 上下文中沒有角色或格式不是 []string 時視為沒有任何角色，回應 403
+func (m *GinMiddleware) RequireAnyRole(roles ...string) gin.HandlerFunc {
+	m.registry.addRoles(roles...)
+
+	required := make([]string, len(roles))
+	for i, role := range roles {
+		required[i] = RoleCheck(role).String()
+	}
+
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			// 沒有提供 token，繼續處理
-			c.Next()
-			return
+		userRoles := contextStrings(c, ContextKeyRoles)
+
+		for _, required := range roles {
+			for _, role := range userRoles {
+				if role == required {
+					c.Next()
+					return
+				}
+			}
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			// token 格式無效，繼續處理但不設置用戶上下文
+		m.logger.Info("Role denied",
+			zap.String("user_id", m.getUserID(c)),
+			zap.Strings("required_roles", roles),
+			zap.Strings("user_roles", userRoles))
+
+		m.denyPermission(c, required, "Insufficient role: required one of ["+strings.Join(roles, ", ")+"]")
+	}
+}
+
+// OptionalAuthConfig OptionalAuth 設定
+type OptionalAuthConfig struct {
+	// Extractors token 來源，為空時使用預設來源（TokenExtractor 或 Authorization 標頭）
+	Extractors []TokenExtractor
+	// RejectDisabledUsers 帶有效 token 但用戶已停用時回應 403，而非視為匿名繼續處理
+	// 預設（false）維持靜默忽略，適用於不應透露帳號狀態的頁面
+	RejectDisabledUsers bool
+}
+
+// OptionalAuth 可選身份驗證（如果有 token 則驗證，但不強制要求）
+func (m *GinMiddleware) OptionalAuth(extractors ...TokenExtractor) gin.HandlerFunc {
+	return m.OptionalAuthWithConfig(OptionalAuthConfig{Extractors: extractors})
+}
+
+// OptionalAuthWithConfig 依設定建立可選身份驗證
+func (m *GinMiddleware) OptionalAuthWithConfig(config OptionalAuthConfig) gin.HandlerFunc {
+	extractors := config.Extractors
+	if len(extractors) == 0 {
+		extractors = m.defaultExtractors()
+	}
+
+	return func(c *gin.Context) {
+		tokenString, ok := extractToken(c, extractors)
+		if !ok {
+			// 沒有提供 token 或格式無效，繼續處理但不設置用戶上下文
 			c.Next()
 			return
 		}
 
 		// 嘗試驗證 token
-		authResult, err := m.authClient.ValidateTokenWithDynamicAuth(c.Request.Context(), tokenString)
+		authResult, err := m.authClient.ValidateTokenWithDynamicAuth(requestContext(c), tokenString)
+		if err == nil && !authResult.IsActive && config.RejectDisabledUsers {
+			m.recordFailure(c, FailureReasonUserDisabled, tokenString, authResult.Claims.UserID)
+			m.respondForbidden(c, "User account is disabled")
+			return
+		}
 		if err == nil && authResult.IsActive && !authResult.ShouldForceLogout {
 			// token 有效且用戶啟用，設置用戶上下文
-			claims := authResult.Claims
-			c.Set("user_id", claims.UserID)
-			c.Set("username", claims.Username)
-			c.Set("email", claims.Email)
-			c.Set("roles", claims.Roles)
-			c.Set("permissions", authResult.DynamicPermissions)
-			c.Set("token_id", claims.ID)
+			setUserContext(c, authResult)
+			c.Set(ContextKeyValidatedFromCache, authResult.ValidatedFromCache)
+			m.setAuthenticatedLogger(c, authResult.Claims)
+		}
+
+		c.Next()
+	}
+}
+
+// runAuthSuccessHook 執行 OnAuthSuccess 並攔截 panic
+func (m *GinMiddleware) runAuthSuccessHook(ctx context.Context, result *AuthResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			m.logger.Error("OnAuthSuccess hook panicked",
+				zap.String("user_id", result.Claims.UserID),
+				zap.Any("error", recovered))
 		}
+	}()
+	m.OnAuthSuccess(ctx, result)
+}
+
+// runForceLogoutHook 執行 OnForceLogout 並等待其完成或逾時，攔截 panic
+func (m *GinMiddleware) runForceLogoutHook(c *gin.Context, userID string) {
+	if m.OnForceLogout == nil {
+		return
+	}
+
+	timeout := m.ForceLogoutHookTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				m.logger.Error("OnForceLogout hook panicked",
+					zap.String("user_id", userID),
+					zap.Any("error", recovered))
+			}
+		}()
+		m.OnForceLogout(ctx, userID)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logger.Warn("OnForceLogout hook timed out",
+			zap.String("user_id", userID),
+			zap.Duration("timeout", timeout))
+	}
+}
+
+// WithDryRun 回傳啟用 DryRun 的副本，供單一路由試行新的權限要求
+//
+//	r.DELETE("/zones/:id", authMiddleware.WithDryRun().RequirePermission("cdn:zones:delete"), handler)
+func (m *GinMiddleware) WithDryRun() *GinMiddleware {
+	dryRun := *m
+	dryRun.DryRun = true
+	return &dryRun
+}
+
+// denyPermission 呼叫 OnPermissionDenied 後回應 403；DryRun 時改為記錄後繼續處理
+func (m *GinMiddleware) denyPermission(c *gin.Context, required []string, message string) {
+	if m.DryRun {
+		c.Set(ContextKeyDryRunDenied, true)
+		m.logger.Warn("Permission would be denied (dry run)",
+			zap.String("user_id", m.getUserID(c)),
+			zap.String("path", c.FullPath()),
+			zap.Strings("required", required),
+			zap.String("reason", message))
+	}
 
+	if m.OnPermissionDenied != nil {
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					m.logger.Error("OnPermissionDenied hook panicked",
+						zap.String("user_id", m.getUserID(c)),
+						zap.Any("error", recovered))
+				}
+			}()
+			m.OnPermissionDenied(c, required)
+		}()
+	}
+
+	if m.DryRun {
 		c.Next()
+		return
+	}
+
+	var details interface{}
+	switch len(required) {
+	case 0:
+	case 1:
+		details = PermissionDeniedDetails{RequiredPermission: required[0]}
+	default:
+		details = PermissionDeniedDetails{RequiredPermissions: required}
+	}
+	m.abortWithError(c, http.StatusForbidden, ErrorResponse{
+		Success: false,
+		Code:    http.StatusForbidden,
+		Message: message,
+		Error:   "FORBIDDEN",
+		Details: details,
+	})
+}
+
+// recordFailure 將驗證失敗輸出至 FailureSink（未設定時略過）
+func (m *GinMiddleware) recordFailure(c *gin.Context, reason, tokenString, userID string) {
+	if m.FailureSink == nil {
+		return
+	}
+	m.FailureSink.Record(FailureEvent{
+		Reason:      reason,
+		UserID:      userID,
+		ClientIP:    m.IPResolver.ClientIP(c),
+		UserAgent:   c.Request.UserAgent(),
+		TokenPrefix: tokenString[:min(len(tokenString), 20)],
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+	})
+}
+
+// defaultExtractors 預設的 token 擷取器
+// 自動刷新模式下，Authorization 標頭缺失時改從 access token Cookie 讀取
+func (m *GinMiddleware) defaultExtractors() []TokenExtractor {
+	if m.TokenExtractor != nil {
+		return []TokenExtractor{m.TokenExtractor}
+	}
+	extractors := []TokenExtractor{BearerExtractor()}
+	if m.AutoRefresh != nil && m.AutoRefresh.AccessCookieName != "" {
+		extractors = append(extractors, CookieExtractor{Name: m.AutoRefresh.AccessCookieName})
+	}
+	return extractors
+}
+
+// shouldRefresh 判斷 token 是否已進入自動刷新區間
+func (m *GinMiddleware) shouldRefresh(claims *Claims) bool {
+	if claims.ExpiresAt == nil {
+		return false
 	}
+	return time.Until(claims.ExpiresAt.Time) < m.AutoRefresh.Threshold
+}
+
+// refreshAndValidate 以 Cookie 中的 refresh token 換發並驗證新的 access token
+// 換發失敗時以 ErrTokenExpired 包裝，讓用戶端仍收到 token 過期而導向登入
+func (m *GinMiddleware) refreshAndValidate(c *gin.Context) (*AuthResult, error) {
+	pair, err := m.refreshFromCookie(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: refresh failed: %w", ErrTokenExpired, err)
+	}
+	return m.authClient.ValidateTokenWithDynamicAuth(requestContext(c), pair.AccessToken)
+}
+
+// refreshFromCookie 呼叫 RefreshToken 並將新的 token 寫回回應 Cookie
+func (m *GinMiddleware) refreshFromCookie(c *gin.Context) (*TokenPair, error) {
+	cfg := m.AutoRefresh
+	refreshToken, err := c.Cookie(cfg.RefreshCookieName)
+	if err != nil || refreshToken == "" {
+		return nil, errors.New("missing refresh token cookie")
+	}
+
+	refresher, ok := m.authClient.(TokenRefresher)
+	if !ok {
+		return nil, errors.New("auth client does not support token refresh")
+	}
+
+	pair, err := refresher.RefreshToken(c.Request.Context(), refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	path := cfg.CookiePath
+	if path == "" {
+		path = "/"
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	if cfg.AccessCookieName != "" {
+		c.SetCookie(cfg.AccessCookieName, pair.AccessToken, int(pair.ExpiresIn), path, cfg.CookieDomain, cfg.Secure, true)
+	}
+	if pair.RefreshToken != "" {
+		// 未提供有效期限時沿用瀏覽器 session cookie
+		c.SetCookie(cfg.RefreshCookieName, pair.RefreshToken, 0, path, cfg.CookieDomain, cfg.Secure, true)
+	}
+
+	return pair, nil
 }
 
 // checkPermission 檢查用戶是否擁有指定權限
 func (m *GinMiddleware) checkPermission(userPermissions []string, requiredPermission string) bool {
+	return hasPermission(userPermissions, requiredPermission)
+}
+
+// hasPermission 檢查權限列表是否滿足指定權限（含萬用字元）
+func hasPermission(userPermissions []string, requiredPermission string) bool {
 	for _, perm := range userPermissions {
 		// 檢查完全匹配
 		if perm == requiredPermission {
 			return true
 		}
-		
+
 		// 檢查萬用字元權限
 		if perm == "*" || perm == "*:*:*" {
 			return true
 		}
-		
+
 		// 檢查部分萬用字元匹配
-		if m.matchesWildcardPermission(perm, requiredPermission) {
+		if matchesWildcardPermission(perm, requiredPermission) {
 			return true
 		}
 	}
@@ -226,7 +576,9 @@ func (m *GinMiddleware) checkPermission(userPermissions []string, requiredPermis
 }
 
 // matchesWildcardPermission 檢查萬用字元權限匹配
-func (m *GinMiddleware) matchesWildcardPermission(userPerm, requiredPerm string) bool {
+// * 匹配恰好一個區段；結尾的 ** 匹配其後一個以上的區段（orders:** 涵蓋 orders:read 與 orders:read:own）
+// 單獨的 ** 沒有前綴區段，不匹配任何權限；需要全部權限請使用 *
+func matchesWildcardPermission(userPerm, requiredPerm string) bool {
 	if !strings.Contains(userPerm, "*") {
 		return false
 	}
@@ -234,6 +586,14 @@ func (m *GinMiddleware) matchesWildcardPermission(userPerm, requiredPerm string)
 	userParts := strings.Split(userPerm, ":")
 	requiredParts := strings.Split(requiredPerm, ":")
 
+	if last := len(userParts) - 1; userParts[last] == "**" {
+		if last == 0 || len(requiredParts) <= last {
+			return false
+		}
+		userParts = userParts[:last]
+		requiredParts = requiredParts[:last]
+	}
+
 	if len(userParts) != len(requiredParts) {
 		return false
 	}
@@ -249,7 +609,7 @@ func (m *GinMiddleware) matchesWildcardPermission(userPerm, requiredPerm string)
 
 // 響應方法
 func (m *GinMiddleware) respondUnauthorized(c *gin.Context, message string) {
-	c.JSON(http.StatusUnauthorized, ErrorResponse{
+	m.abortWithError(c, http.StatusUnauthorized, ErrorResponse{
 		Success: false,
 		Code:    http.StatusUnauthorized,
 		Message: message,
@@ -258,7 +618,7 @@ func (m *GinMiddleware) respondUnauthorized(c *gin.Context, message string) {
 }
 
 func (m *GinMiddleware) respondForbidden(c *gin.Context, message string) {
-	c.JSON(http.StatusForbidden, ErrorResponse{
+	m.abortWithError(c, http.StatusForbidden, ErrorResponse{
 		Success: false,
 		Code:    http.StatusForbidden,
 		Message: message,
@@ -266,12 +626,35 @@ func (m *GinMiddleware) respondForbidden(c *gin.Context, message string) {
 	})
 }
 
+func (m *GinMiddleware) respondServiceUnavailable(c *gin.Context, message string) {
+	c.Header("Retry-After", "1")
+	m.abortWithError(c, http.StatusServiceUnavailable, ErrorResponse{
+		Success: false,
+		Code:    http.StatusServiceUnavailable,
+		Message: message,
+		Error:   "SERVICE_UNAVAILABLE",
+	})
+}
+
+// abortWithError 寫入錯誤回應並中止後續處理器
+// 寫入與中止同時完成，確保拒絕後不會再執行下游處理器；若回應已被寫出則僅中止並記錄
+func (m *GinMiddleware) abortWithError(c *gin.Context, status int, resp ErrorResponse) {
+	if c.Writer.Written() {
+		m.logger.Error("Response already written before auth rejection",
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("written_status", c.Writer.Status()),
+			zap.Int("rejection_status", status))
+		c.Abort()
+		return
+	}
+	resp.Message = response.Translate(c, resp.Error, resp.Message)
+	c.AbortWithStatusJSON(status, resp)
+}
+
 // 輔助方法
 func (m *GinMiddleware) getUserID(c *gin.Context) string {
-	if userID, exists := c.Get("user_id"); exists {
-		if userIDStr, ok := userID.(string); ok {
-			return userIDStr
-		}
+	if userID, ok := GetUserID(c); ok {
+		return userID
 	}
 	return "unknown"
 }
@@ -281,4 +664,4 @@ func min(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
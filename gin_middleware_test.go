@@ -0,0 +1,533 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// stubAuthClient 依 token 回傳預先設定結果的 AuthClient，未實作任何選用介面
+type stubAuthClient struct {
+	results map[string]*AuthResult
+	errs    map[string]error
+}
+
+func (s *stubAuthClient) ValidateToken(tokenString string) (*Claims, error) {
+	result, err := s.ValidateTokenWithDynamicAuth(context.Background(), tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return result.Claims, nil
+}
+
+func (s *stubAuthClient) ValidateTokenWithDynamicAuth(_ context.Context, tokenString string) (*AuthResult, error) {
+	if err, ok := s.errs[tokenString]; ok {
+		return nil, err
+	}
+	if result, ok := s.results[tokenString]; ok {
+		return result, nil
+	}
+	return nil, ErrInvalidSignature
+}
+
+func (s *stubAuthClient) CheckUserStatus(context.Context, string) (bool, error) { return true, nil }
+
+func (s *stubAuthClient) CheckForceLogout(context.Context, string, int64) (bool, error) {
+	return false, nil
+}
+
+func (s *stubAuthClient) GetUserDynamicPermissions(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *stubAuthClient) SetUserStatus(context.Context, string, bool) error { return nil }
+
+func (s *stubAuthClient) SetForceLogout(context.Context, string) error { return nil }
+
+// refreshingAuthClient 另實作 TokenRefresher 的 stubAuthClient
+type refreshingAuthClient struct {
+	*stubAuthClient
+	pair *TokenPair
+	err  error
+}
+
+func (r *refreshingAuthClient) RefreshToken(context.Context, string) (*TokenPair, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.pair, nil
+}
+
+// activeResult 回傳指定用戶與權限的有效驗證結果
+func activeResult(userID string, permissions ...string) *AuthResult {
+	return &AuthResult{
+		Claims:             &Claims{UserID: userID, Permissions: permissions},
+		DynamicPermissions: permissions,
+		IsActive:           true,
+	}
+}
+
+// serve 以單一路由執行 handlers 並回傳回應與最終 handler 是否被呼叫
+func serve(req *http.Request, handlers ...gin.HandlerFunc) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	router := gin.New()
+	router.GET("/resource", append(handlers, func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusOK)
+	})...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, reached
+}
+
+func TestAuthenticateAutoRefresh(t *testing.T) {
+	stub := &stubAuthClient{
+		results: map[string]*AuthResult{"fresh": activeResult("42")},
+		errs:    map[string]error{"expired": ErrTokenExpired},
+	}
+	autoRefresh := &AutoRefreshConfig{RefreshCookieName: "refresh_token", AccessCookieName: "access_token"}
+
+	tests := []struct {
+		name        string
+		client      AuthClient
+		wantStatus  int
+		wantRefresh bool
+	}{
+		{
+			name:       "client without TokenRefresher",
+			client:     stub,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "client with TokenRefresher",
+			client:      &refreshingAuthClient{stubAuthClient: stub, pair: &TokenPair{AccessToken: "fresh", ExpiresIn: 900}},
+			wantStatus:  http.StatusOK,
+			wantRefresh: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(tt.client, zap.NewNop())
+			m.AutoRefresh = autoRefresh
+
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+			req.Header.Set("Authorization", "Bearer expired")
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+
+			w, reached := serve(req, m.Authenticate())
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v", reached)
+			}
+
+			var refreshed bool
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.Name == "access_token" && cookie.Value == "fresh" {
+					refreshed = true
+				}
+			}
+			if refreshed != tt.wantRefresh {
+				t.Errorf("access cookie refreshed = %v, want %v", refreshed, tt.wantRefresh)
+			}
+		})
+	}
+}
+
+func TestAuthenticateExpiredTokenRefreshFails(t *testing.T) {
+	stub := &stubAuthClient{errs: map[string]error{"expired": ErrTokenExpired}}
+
+	tests := []struct {
+		name   string
+		client AuthClient
+		cookie string
+	}{
+		{name: "no refresh cookie", client: &refreshingAuthClient{stubAuthClient: stub, pair: &TokenPair{AccessToken: "fresh"}}},
+		{name: "invalid refresh token", client: &refreshingAuthClient{stubAuthClient: stub, err: errors.New("refresh token revoked")}, cookie: "revoked"},
+		{name: "client without TokenRefresher", client: stub, cookie: "refresh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, redisClient := newTestRedis(t)
+			sinkClient := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t), RedisClient: redisClient})
+			m := NewGinMiddleware(tt.client, zap.NewNop())
+			m.AutoRefresh = &AutoRefreshConfig{RefreshCookieName: "refresh_token", AccessCookieName: "access_token"}
+			m.FailureSink = sinkClient.NewFailureSink(FailureSinkConfig{Stream: "auth:failures"})
+
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+			req.Header.Set("Authorization", "Bearer expired")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.cookie})
+			}
+
+			w, reached := serve(req, m.Authenticate())
+			if reached || w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, reached = %v; want 401", w.Code, reached)
+			}
+			if resp := decodeErrorResponse(t, w); resp.Message != "Token has expired" {
+				t.Errorf("message = %q, want %q", resp.Message, "Token has expired")
+			}
+
+			m.FailureSink.Flush(context.Background())
+			entries, err := redisClient.XRange(context.Background(), "auth:failures", "-", "+").Result()
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("failure events = %v, %v; want one", entries, err)
+			}
+			if reason := entries[0].Values["reason"]; reason != FailureReasonTokenExpired {
+				t.Errorf("failure reason = %v, want %s", reason, FailureReasonTokenExpired)
+			}
+		})
+	}
+}
+
+// decodeErrorResponse 解析回應內容為 ErrorResponse
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+func TestRequireAnyPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		required    []string
+		wantStatus  int
+		wantMessage string // 回應訊息應包含的片段
+	}{
+		{
+			name:        "nil permission set",
+			permissions: nil,
+			required:    []string{"user:read", "user:write"},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "user has no permissions",
+		},
+		{
+			name:        "empty permission set",
+			permissions: []string{},
+			required:    []string{"user:read"},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "user has no permissions",
+		},
+		{
+			name:        "wildcard-only permission set",
+			permissions: []string{"*"},
+			required:    []string{"user:read", "user:write"},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "segment wildcard-only permission set",
+			permissions: []string{"*:*:*"},
+			required:    []string{"user:read"},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "one of several matches",
+			permissions: []string{"user:write"},
+			required:    []string{"user:read", "user:write"},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "none match",
+			permissions: []string{"order:read"},
+			required:    []string{"user:read", "user:write"},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "required one of [user:read, user:write]",
+		},
+		{
+			name:        "no required permissions",
+			permissions: []string{"*"},
+			required:    nil,
+			wantStatus:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+
+			w, reached := serve(req, withUser(nil, tt.permissions), m.RequireAnyPermission(tt.required...))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v", reached)
+			}
+			if tt.wantMessage != "" {
+				if resp := decodeErrorResponse(t, w); !strings.Contains(resp.Message, tt.wantMessage) {
+					t.Errorf("message = %q, want it to contain %q", resp.Message, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireAnyPermissionWithoutContext(t *testing.T) {
+	m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+
+	w, reached := serve(req, m.RequireAnyPermission("user:read"))
+	if w.Code != http.StatusForbidden || reached {
+		t.Fatalf("status = %d, reached = %v; want 403 without reaching the handler", w.Code, reached)
+	}
+	if resp := decodeErrorResponse(t, w); resp.Message != "No permissions found" {
+		t.Errorf("message = %q", resp.Message)
+	}
+}
+
+func TestRejectionAbortsBeforeHandler(t *testing.T) {
+	stub := &stubAuthClient{results: map[string]*AuthResult{
+		"reader": activeResult("42", "user:read"),
+		"writer": activeResult("43", "user:write"),
+	}}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "missing permission", token: "reader", wantStatus: http.StatusForbidden, wantError: "FORBIDDEN"},
+		{name: "invalid token", token: "forged", wantStatus: http.StatusUnauthorized, wantError: "UNAUTHORIZED"},
+		{name: "allowed", token: "writer", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(stub, zap.NewNop())
+			router := gin.New()
+			reached := false
+			router.GET("/resource", m.Authenticate(), m.RequirePermission("user:write"), func(c *gin.Context) {
+				reached = true
+				c.String(http.StatusOK, "handler output")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if !reached || w.Body.String() != "handler output" {
+					t.Fatalf("reached = %v, body = %q", reached, w.Body)
+				}
+				return
+			}
+			if reached {
+				t.Fatal("downstream handler ran after rejection")
+			}
+
+			// 回應內容必須恰好是一個錯誤信封，不含處理器輸出
+			resp := decodeErrorResponse(t, w)
+			envelope, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if w.Body.String() != string(envelope) {
+				t.Errorf("body = %q, want exactly %q", w.Body, envelope)
+			}
+			if resp.Success || resp.Code != tt.wantStatus || resp.Error != tt.wantError {
+				t.Errorf("envelope = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRejectionAfterResponseWritten(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	m := NewGinMiddleware(&stubAuthClient{}, zap.New(core))
+
+	earlyWriter := func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		c.Next()
+	}
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	w, reached := serve(req, earlyWriter, withUser(nil, []string{"user:read"}), m.RequirePermission("user:write"))
+
+	if reached {
+		t.Fatal("downstream handler ran after rejection")
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the already written 200 %q only", w.Code, w.Body, "partial")
+	}
+	if got := logs.FilterMessage("Response already written before auth rejection").Len(); got != 1 {
+		t.Errorf("already-written errors logged = %d, want 1", got)
+	}
+}
+
+// orderRecorder 記錄回應標頭寫出的時間點，用於確認 hook 在回應前執行
+type orderRecorder struct {
+	*httptest.ResponseRecorder
+	record func(event string)
+}
+
+func (r orderRecorder) WriteHeader(code int) {
+	r.record("response")
+	r.ResponseRecorder.WriteHeader(code)
+}
+
+// serveForceLogout 以 Authenticate 處理一個會被強制登出的請求，回傳回應與依序發生的事件
+func serveForceLogout(t *testing.T, m *GinMiddleware, hook func(ctx context.Context, userID string, record func(string))) (*httptest.ResponseRecorder, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	m.OnForceLogout = func(ctx context.Context, userID string) { hook(ctx, userID, record) }
+
+	router := gin.New()
+	router.GET("/resource", m.Authenticate(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("Authorization", "Bearer forced")
+	w := orderRecorder{ResponseRecorder: httptest.NewRecorder(), record: record}
+	router.ServeHTTP(w, req)
+
+	return w.ResponseRecorder, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(events)
+	}
+}
+
+// forcedLogoutClient 回傳對 token "forced" 要求強制登出的 stubAuthClient
+func forcedLogoutClient() *stubAuthClient {
+	return &stubAuthClient{results: map[string]*AuthResult{"forced": {
+		Claims:            &Claims{UserID: "42"},
+		IsActive:          true,
+		ShouldForceLogout: true,
+	}}}
+}
+
+func TestForceLogoutHookRunsBeforeResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		hook       func(ctx context.Context, userID string, record func(string))
+		wantEvents []string
+	}{
+		{
+			name:       "completes before 401",
+			hook:       func(_ context.Context, userID string, record func(string)) { record("hook " + userID) },
+			wantEvents: []string{"hook 42", "response"},
+		},
+		{
+			name:       "panic is recovered",
+			hook:       func(context.Context, string, func(string)) { panic("boom") },
+			wantEvents: []string{"response"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(forcedLogoutClient(), zap.NewNop())
+			w, events := serveForceLogout(t, m, tt.hook)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", w.Code)
+			}
+			if got := events(); !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestForceLogoutHookTimeout(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	m := NewGinMiddleware(forcedLogoutClient(), zap.New(core))
+	m.ForceLogoutHookTimeout = 50 * time.Millisecond
+
+	release := make(chan struct{})
+	hookErr := make(chan error, 1)
+	start := time.Now()
+	w, events := serveForceLogout(t, m, func(ctx context.Context, _ string, record func(string)) {
+		<-release
+		record("hook")
+		hookErr <- ctx.Err()
+	})
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if elapsed > time.Second {
+		t.Errorf("response took %v, want about the 50ms hook timeout", elapsed)
+	}
+	if got := logs.FilterMessage("OnForceLogout hook timed out").Len(); got != 1 {
+		t.Errorf("hook timeout warnings = %d, want 1", got)
+	}
+
+	// 逾時後照常回應，hook 仍在背景執行，其 ctx 已逾時
+	close(release)
+	if err := <-hookErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("hook ctx err = %v, want DeadlineExceeded", err)
+	}
+	if got := events(); !slices.Equal(got, []string{"response", "hook"}) {
+		t.Errorf("events = %v, want [response hook]", got)
+	}
+}
+
+func TestAuthSuccessHookGetsCopy(t *testing.T) {
+	result := activeResult("42", "orders:read")
+	result.Claims.Roles = []string{"admin"}
+	m := NewGinMiddleware(&stubAuthClient{results: map[string]*AuthResult{"valid": result}}, zap.NewNop())
+
+	hookDone := make(chan struct{})
+	m.OnAuthSuccess = func(_ context.Context, got *AuthResult) {
+		defer close(hookDone)
+		got.DynamicPermissions[0] = "orders:write"
+		got.Claims.Roles[0] = "guest"
+		got.Claims.Permissions[0] = "orders:write"
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	var permissions, roles []string
+	w, reached := serve(req, m.Authenticate(), func(c *gin.Context) {
+		// 與 hook 並行讀取，-race 下可偵測共用切片
+		permissions, _ = GetPermissions(c)
+		roles, _ = GetRoles(c)
+		_ = permissions[0] + roles[0]
+		<-hookDone
+		c.Next()
+	})
+
+	if w.Code != http.StatusOK || !reached {
+		t.Fatalf("status = %d, reached = %v", w.Code, reached)
+	}
+	if !slices.Equal(permissions, []string{"orders:read"}) || !slices.Equal(roles, []string{"admin"}) {
+		t.Errorf("context permissions = %v, roles = %v; mutated by OnAuthSuccess", permissions, roles)
+	}
+	if result.DynamicPermissions[0] != "orders:read" || result.Claims.Roles[0] != "admin" || result.Claims.Permissions[0] != "orders:read" {
+		t.Errorf("validation result mutated by OnAuthSuccess: %+v", result)
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout ctx 未設定期限時 CheckHealth 的時間上限
+const healthCheckTimeout = 3 * time.Second
+
+// CheckHealth 檢查的依賴名稱
+const (
+	HealthDependencyRedis       = "redis"
+	HealthDependencyRedisRead   = "redis_read"   // 僅在設定 RedisReadAddr 時檢查
+	HealthDependencyStore       = "store"        // 僅在 Config.Store 實作 HealthCheckStore 時檢查
+	HealthDependencyAuthService = "auth_service" // 僅在設定 AuthServiceURL 時檢查
+)
+
+// ErrUnhealthy 有依賴未通過健康檢查
+var ErrUnhealthy = errors.New("auth dependency unhealthy")
+
+// HealthReport 健康檢查結果
+type HealthReport struct {
+	Healthy      bool                        `json:"healthy"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// DependencyHealth 單一依賴的檢查結果
+type DependencyHealth struct {
+	Healthy   bool   `json:"healthy"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Err 回傳包裝 ErrUnhealthy 的錯誤，列出每個失敗的依賴；全部正常時回傳 nil
+func (r *HealthReport) Err() error {
+	var names []string
+	for name, dep := range r.Dependencies {
+		if !dep.Healthy {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrUnhealthy, name, r.Dependencies[name].Error))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckHealth 並行檢查 Redis（含唯讀副本）與 Auth 服務（設定 AuthServiceURL 時），供 readiness probe 使用
+// 設定 Store 時改為檢查該儲存（須實作 HealthCheckStore，否則略過）；另提供 RedisClient 時仍檢查 Redis，供 FailureSink 等使用
+// Auth 服務以 GET {AuthServiceURL}{AuthServiceHealthPath} 檢查，回應 2xx 視為正常
+// ctx 未設定期限時最多等待 3 秒
+func (c *Client) CheckHealth(ctx context.Context) *HealthReport {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+	}
+
+	checks := make(map[string]func(context.Context) error)
+	if c.redisClient != nil {
+		checks[HealthDependencyRedis] = func(ctx context.Context) error {
+			return c.redisClient.Ping(ctx).Err()
+		}
+	}
+	if c.config.RedisReadAddr != "" && c.config.Store == nil {
+		checks[HealthDependencyRedisRead] = func(ctx context.Context) error {
+			return c.readClient.Ping(ctx).Err()
+		}
+	}
+	if store, ok := c.config.Store.(HealthCheckStore); ok {
+		checks[HealthDependencyStore] = store.Ping
+	}
+	if c.config.AuthServiceURL != "" {
+		checks[HealthDependencyAuthService] = c.checkAuthService
+	}
+
+	report := &HealthReport{Healthy: true, Dependencies: make(map[string]DependencyHealth, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			dep := DependencyHealth{Healthy: err == nil, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				dep.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = dep
+			if err != nil {
+				report.Healthy = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// HealthCheck 同 CheckHealth，只回傳錯誤：任一依賴失敗時回傳包裝 ErrUnhealthy 的錯誤，訊息中標示失敗的依賴
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return c.CheckHealth(ctx).Err()
+}
+
+// checkAuthService 對 Auth 服務的健康檢查端點發出 GET 請求
+func (c *Client) checkAuthService(ctx context.Context) error {
+	path := c.config.AuthServiceHealthPath
+	if path == "" {
+		path = "/health"
+	}
+	endpoint := strings.TrimRight(c.config.AuthServiceURL, "/") + "/" + strings.TrimLeft(path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build health check request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
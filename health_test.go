@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// dependencyNames 回傳報告中檢查過的依賴名稱（已排序）
+func dependencyNames(report *HealthReport) []string {
+	var names []string
+	for name := range report.Dependencies {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestCheckHealthDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    func(storeClient, redisClient *redis.Client) *Config
+		storeDown bool
+		wantDeps  []string
+		wantErr   string
+	}{
+		{
+			name:     "default redis store",
+			config:   func(_, redisClient *redis.Client) *Config { return &Config{RedisClient: redisClient} },
+			wantDeps: []string{HealthDependencyRedis},
+		},
+		{
+			name: "custom store is checked instead of redis",
+			config: func(storeClient, _ *redis.Client) *Config {
+				return &Config{Store: NewRedisStore(storeClient, RedisStoreConfig{})}
+			},
+			wantDeps: []string{HealthDependencyStore},
+		},
+		{
+			name: "custom store down",
+			config: func(storeClient, _ *redis.Client) *Config {
+				return &Config{Store: NewRedisStore(storeClient, RedisStoreConfig{})}
+			},
+			storeDown: true,
+			wantDeps:  []string{HealthDependencyStore},
+			wantErr:   HealthDependencyStore,
+		},
+		{
+			name: "custom store without health check is skipped",
+			config: func(storeClient, _ *redis.Client) *Config {
+				return &Config{Store: singlePermissionStore{NewRedisStore(storeClient, RedisStoreConfig{})}}
+			},
+		},
+		{
+			name: "explicit redis client is still checked",
+			config: func(storeClient, redisClient *redis.Client) *Config {
+				return &Config{Store: NewRedisStore(storeClient, RedisStoreConfig{}), RedisClient: redisClient}
+			},
+			storeDown: true,
+			wantDeps:  []string{HealthDependencyRedis, HealthDependencyStore},
+			wantErr:   HealthDependencyStore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeServer := miniredis.RunT(t)
+			storeClient := redis.NewClient(&redis.Options{Addr: storeServer.Addr(), MaxRetries: -1})
+			t.Cleanup(func() { storeClient.Close() })
+			_, redisClient := newTestRedis(t)
+
+			config := tt.config(storeClient, redisClient)
+			config.PublicKeyPath = mustRSAKeyPath(t)
+			client := newTestClient(t, config)
+			if tt.storeDown {
+				storeServer.Close()
+			}
+
+			report := client.CheckHealth(context.Background())
+			if got := dependencyNames(report); !slices.Equal(got, tt.wantDeps) {
+				t.Fatalf("checked dependencies = %v, want %v", got, tt.wantDeps)
+			}
+
+			err := report.Err()
+			if tt.wantErr == "" {
+				if err != nil || !report.Healthy {
+					t.Errorf("report unhealthy: %v", err)
+				}
+				return
+			}
+			if report.Healthy || !errors.Is(err, ErrUnhealthy) || !strings.Contains(err.Error(), tt.wantErr+":") {
+				t.Errorf("report.Err() = %v, want ErrUnhealthy for %s", err, tt.wantErr)
+			}
+			if dep := report.Dependencies[HealthDependencyRedis]; slices.Contains(tt.wantDeps, HealthDependencyRedis) && !dep.Healthy {
+				t.Errorf("redis dependency unhealthy: %+v", dep)
+			}
+		})
+	}
+}
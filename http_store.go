@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrReadOnlyStore 儲存不支援寫入
+var ErrReadOnlyStore = errors.New("store is read-only")
+
+// HTTPStore 透過 Auth 服務 HTTP API 查詢的唯讀 PermissionStore，作為 Redis 無法使用時的降級層
+//
+//	GET {base}/api/v1/auth/users/{user_id}/status        → {"is_active": true, "updated_at": "..."}
+//	GET {base}/api/v1/auth/users/{user_id}/force-logout  → {"force_logout_at": 1672531200}
+//	GET {base}/api/v1/auth/users/{user_id}/permissions   → {"permissions": ["a", "b"]}
+//
+// 回應可直接為上述物件，或包在統一回應格式 {"success": true, "data": {...}} 中；
+// 非 200 狀態（含 404）一律視為查詢失敗，避免 AuthServiceURL 設定錯誤或路由缺失時所有用戶都被當成啟用且未強制登出；
+// 資料不存在須以 200 明確表示，如未設定強制登出時回傳 {"force_logout_at": null}
+type HTTPStore struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHTTPStore 建立 HTTP 儲存，httpClient 為 nil 時使用 5 秒逾時的預設客戶端
+func NewHTTPStore(baseURL string, httpClient *http.Client) *HTTPStore {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &HTTPStore{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// GetUserStatus 查詢用戶狀態，回應缺少 is_active 時視為查詢失敗，不可預設為停用或啟用
+func (s *HTTPStore) GetUserStatus(ctx context.Context, userID string) (*UserStatus, error) {
+	var body struct {
+		IsActive  *bool     `json:"is_active"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	if err := s.get(ctx, userID, "status", &body); err != nil {
+		return nil, err
+	}
+	if body.IsActive == nil {
+		return nil, errors.New("status response has no is_active field")
+	}
+	return &UserStatus{IsActive: *body.IsActive, UpdatedAt: body.UpdatedAt}, nil
+}
+
+// GetForceLogout 查詢強制登出時間，回應的 force_logout_at 為 null 或未提供時回傳 ErrCacheNotFound
+func (s *HTTPStore) GetForceLogout(ctx context.Context, userID string) (int64, error) {
+	var body struct {
+		ForceLogoutAt *int64 `json:"force_logout_at"`
+	}
+	if err := s.get(ctx, userID, "force-logout", &body); err != nil {
+		return 0, err
+	}
+	if body.ForceLogoutAt == nil {
+		return 0, ErrCacheNotFound
+	}
+	return *body.ForceLogoutAt, nil
+}
+
+// GetDynamicPermissions 查詢動態權限
+func (s *HTTPStore) GetDynamicPermissions(ctx context.Context, userID string) ([]string, error) {
+	var body struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := s.get(ctx, userID, "permissions", &body); err != nil {
+		return nil, err
+	}
+	return body.Permissions, nil
+}
+
+// SetUserStatus 不支援
+func (s *HTTPStore) SetUserStatus(context.Context, string, UserStatus) error {
+	return ErrReadOnlyStore
+}
+
+// SetForceLogout 不支援
+func (s *HTTPStore) SetForceLogout(context.Context, string, int64) error {
+	return ErrReadOnlyStore
+}
+
+// ClearForceLogout 不支援
+func (s *HTTPStore) ClearForceLogout(context.Context, string) error {
+	return ErrReadOnlyStore
+}
+
+// get 呼叫查詢端點並解析回應內容至 out
+func (s *HTTPStore) get(ctx context.Context, userID, resource string, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/api/v1/auth/users/%s/%s", s.baseURL, url.PathEscape(userID), resource)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", resource, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s endpoint: %w", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s endpoint %s returned status %d", resource, endpoint, resp.StatusCode)
+	}
+
+	// 兼容統一回應格式 {"success": true, "data": {...}} 與直接回傳內容
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	raw := json.RawMessage{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", resource, err)
+	}
+	if err := json.Unmarshal(raw, &envelope); err == nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+		raw = envelope.Data
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", resource, err)
+	}
+	return nil
+}
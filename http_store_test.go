@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newAuthServiceStub 依路徑回傳固定狀態碼與內容的 Auth 服務替身
+func newAuthServiceStub(t *testing.T, routes map[string]struct {
+	status int
+	body   string
+}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(route.status)
+		_, _ = w.Write([]byte(route.body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPStoreGetUserStatus(t *testing.T) {
+	server := newAuthServiceStub(t, map[string]struct {
+		status int
+		body   string
+	}{
+		"/api/v1/auth/users/active/status":   {http.StatusOK, `{"is_active": true}`},
+		"/api/v1/auth/users/inactive/status": {http.StatusOK, `{"success": true, "data": {"is_active": false}}`},
+		"/api/v1/auth/users/missing/status":  {http.StatusOK, `{"updated_at": "2024-01-01T00:00:00Z"}`},
+		"/api/v1/auth/users/wrapped/status":  {http.StatusOK, `{"success": true, "data": {}}`},
+		"/api/v1/auth/users/broken/status":   {http.StatusInternalServerError, `oops`},
+	})
+	store := NewHTTPStore(server.URL, server.Client())
+
+	tests := []struct {
+		userID       string
+		wantActive   bool
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{userID: "active", wantActive: true},
+		{userID: "inactive", wantActive: false},
+		{userID: "missing", wantErr: true},
+		{userID: "wrapped", wantErr: true},
+		{userID: "broken", wantErr: true},
+		{userID: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			status, err := store.GetUserStatus(context.Background(), tt.userID)
+			switch {
+			case tt.wantNotFound:
+				if !errors.Is(err, ErrCacheNotFound) {
+					t.Fatalf("err = %v, want ErrCacheNotFound", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrCacheNotFound) {
+					t.Fatalf("GetUserStatus = %+v, %v; want a lookup error", status, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("GetUserStatus: %v", err)
+				}
+				if status.IsActive != tt.wantActive {
+					t.Errorf("IsActive = %v, want %v", status.IsActive, tt.wantActive)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPStoreGetForceLogout(t *testing.T) {
+	server := newAuthServiceStub(t, map[string]struct {
+		status int
+		body   string
+	}{
+		"/api/v1/auth/users/1/force-logout": {http.StatusOK, `{"force_logout_at": 1672531200}`},
+		"/api/v1/auth/users/2/force-logout": {http.StatusOK, `{}`},
+		"/api/v1/auth/users/3/force-logout": {http.StatusOK, `{"success": true, "data": {"force_logout_at": null}}`},
+	})
+	store := NewHTTPStore(server.URL, server.Client())
+
+	if got, err := store.GetForceLogout(context.Background(), "1"); err != nil || got != 1672531200 {
+		t.Errorf("GetForceLogout(1) = %d, %v; want 1672531200", got, err)
+	}
+	for _, userID := range []string{"2", "3"} {
+		if _, err := store.GetForceLogout(context.Background(), userID); !errors.Is(err, ErrCacheNotFound) {
+			t.Errorf("GetForceLogout(%s) err = %v, want ErrCacheNotFound", userID, err)
+		}
+	}
+	if _, err := store.GetForceLogout(context.Background(), "unknown"); err == nil || errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetForceLogout(unknown) err = %v, want a lookup error for 404", err)
+	}
+}
+
+func TestHTTPStoreMisroutedURL(t *testing.T) {
+	// AuthServiceURL 指向沒有查詢端點的服務：每個請求都是 404，不可被當成資料不存在
+	server := newAuthServiceStub(t, nil)
+	store := NewHTTPStore(server.URL+"/wrong-prefix", server.Client())
+	ctx := context.Background()
+
+	if status, err := store.GetUserStatus(ctx, "42"); err == nil || errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetUserStatus = %+v, %v; want a lookup error", status, err)
+	}
+	if _, err := store.GetForceLogout(ctx, "42"); err == nil || errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetForceLogout err = %v, want a lookup error", err)
+	}
+	if perms, err := store.GetDynamicPermissions(ctx, "42"); err == nil || errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetDynamicPermissions = %v, %v; want a lookup error", perms, err)
+	}
+}
+
+func TestMisroutedHTTPFallbackFailsClosed(t *testing.T) {
+	server := newAuthServiceStub(t, nil)
+	redisServer, redisClient := newTestRedis(t)
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{
+		PublicKeyPath:  path,
+		RedisClient:    redisClient,
+		AuthServiceURL: server.URL,
+		FailClosed:     true,
+	})
+	redisServer.SetError("connection refused")
+
+	// Redis 與 HTTP 層都失敗（HTTP 層為 404），FailClosed 時必須拒絕，而非視為啟用且未強制登出
+	token := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("42"), nil)
+	if result, err := client.ValidateTokenWithDynamicAuth(context.Background(), token); !errors.Is(err, ErrDynamicAuthUnavailable) {
+		t.Errorf("ValidateTokenWithDynamicAuth = %+v, %v; want ErrDynamicAuthUnavailable", result, err)
+	}
+	if stats := client.StoreStats(); stats["http"].Failures == 0 || stats["http"].Served != 0 {
+		t.Errorf("http tier stats = %+v, want failures only", stats["http"])
+	}
+}
+
+func TestHTTPFallbackEnabledByAuthServiceURL(t *testing.T) {
+	_, path := newRSAKey(t)
+
+	client := newTestClient(t, &Config{PublicKeyPath: path})
+	if stats := client.StoreStats(); stats != nil {
+		t.Errorf("StoreStats() = %v, want no fallback chain without AuthServiceURL", stats)
+	}
+
+	client = newTestClient(t, &Config{PublicKeyPath: path, AuthServiceURL: "http://auth-service.invalid"})
+	if _, ok := client.StoreStats()["http"]; !ok {
+		t.Errorf("StoreStats() = %v, want an http tier with AuthServiceURL", client.StoreStats())
+	}
+
+	client = newTestClient(t, &Config{PublicKeyPath: path, AuthServiceURL: "http://auth-service.invalid", DisableHTTPFallback: true})
+	if stats := client.StoreStats(); stats != nil {
+		t.Errorf("StoreStats() = %v, want no fallback chain with DisableHTTPFallback", stats)
+	}
+}
+
+func TestHTTPFallbackServesWhenRedisDown(t *testing.T) {
+	stub := newAuthServiceStub(t, map[string]struct {
+		status int
+		body   string
+	}{
+		"/api/v1/auth/users/42/status": {http.StatusOK, `{"is_active": false}`},
+	})
+	server, redisClient := newTestRedis(t)
+	client := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t), RedisClient: redisClient, AuthServiceURL: stub.URL})
+	server.SetError("connection refused")
+
+	active, err := client.CheckUserStatus(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("CheckUserStatus: %v", err)
+	}
+	if active {
+		t.Error("CheckUserStatus = true, want the HTTP tier's inactive status")
+	}
+	if stats := client.StoreStats(); stats["http"].Served != 1 || stats["redis"].Failures != 1 {
+		t.Errorf("StoreStats() = %+v", stats)
+	}
+}
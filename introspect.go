@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultIntrospectPermission 內省端點預設要求的服務權限
+const DefaultIntrospectPermission = "auth:tokens:introspect"
+
+// IntrospectionResponse token 內省結果（參照 OAuth2 Token Introspection, RFC 7662）
+type IntrospectionResponse struct {
+	Active      bool     `json:"active"`
+	Claims      *Claims  `json:"claims,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// introspectRequest JSON 請求內容
+type introspectRequest struct {
+	Token string `json:"token"`
+}
+
+// RegisterIntrospection 掛載 POST /introspect，並要求 permission 權限（為空時使用 DefaultIntrospectPermission）
+func (h *AdminHandlers) RegisterIntrospection(rg *gin.RouterGroup, permission string) {
+	if permission == "" {
+		permission = DefaultIntrospectPermission
+	}
+
+	rg.POST("/introspect", h.middleware.Authenticate(), h.middleware.RequirePermission(permission), h.IntrospectHandler())
+}
+
+// IntrospectHandler 驗證請求內容中的 token（含動態檢查），供 API Gateway 卸載驗證
+// token 可由表單欄位 token 或 JSON {"token": "..."} 提供；
+// 依內省慣例，無效、停用或已強制登出的 token 回傳 active:false 而非錯誤
+func (h *AdminHandlers) IntrospectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.PostForm("token")
+		if token == "" && strings.HasPrefix(c.ContentType(), "application/json") {
+			var req introspectRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				response.BadRequest(c, "Invalid request body", err.Error())
+				return
+			}
+			token = req.Token
+		}
+		if token == "" {
+			response.BadRequest(c, "Missing token")
+			return
+		}
+
+		result, err := h.authClient.ValidateTokenWithDynamicAuth(requestContext(c), token)
+		if err != nil {
+			h.logger.Debug("Introspected token is invalid", zap.Error(err))
+			response.Success(c, IntrospectionResponse{Active: false})
+			return
+		}
+		if !result.IsActive || result.ShouldForceLogout {
+			response.Success(c, IntrospectionResponse{Active: false})
+			return
+		}
+
+		response.Success(c, IntrospectionResponse{
+			Active:      true,
+			Claims:      result.Claims,
+			Permissions: result.DynamicPermissions,
+		})
+	}
+}
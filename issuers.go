@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// IssuerConfig 多發行者設定中單一發行者的金鑰與簽名演算法
+// iss 相符的 token 只會以此處的金鑰與演算法驗證，避免以 A 發行者的演算法冒用 B 發行者的 token
+type IssuerConfig struct {
+	Issuer        string // 發行者（完整比對）
+	PublicKeyPath string // 公鑰路徑（與 JWKSURL 擇一）
+	JWKSURL       string // JWKS 端點，依 token 的 kid 選擇金鑰
+	KeyID         string // 公鑰的 key ID，用法同 Config.KeyID
+
+	// SigningAlgorithms 此發行者使用的簽名演算法，預設為 RS256/RS384/RS512；none 與 HMAC 一律拒絕
+	SigningAlgorithms []string
+}
+
+// issuerKeys 單一發行者的驗證金鑰與允許的演算法
+type issuerKeys struct {
+	publicKey  interface{}
+	jwks       *jwksProvider
+	keyID      string
+	algorithms []string
+}
+
+// newIssuerKeys 依 Config.Issuers 載入各發行者的金鑰，回傳的 closers 為需於 Close 時釋放的 JWKS 來源
+func newIssuerKeys(config *Config, httpClient *http.Client) (map[string]*issuerKeys, []io.Closer, error) {
+	if len(config.Issuers) == 0 {
+		return nil, nil, nil
+	}
+
+	issuers := make(map[string]*issuerKeys, len(config.Issuers))
+	var closers []io.Closer
+	closeAll := func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}
+
+	for _, ic := range config.Issuers {
+		if ic.Issuer == "" {
+			closeAll()
+			return nil, nil, errors.New("issuer config has an empty Issuer")
+		}
+		if _, exists := issuers[ic.Issuer]; exists {
+			closeAll()
+			return nil, nil, fmt.Errorf("duplicate issuer config for %q", ic.Issuer)
+		}
+		if (ic.PublicKeyPath == "") == (ic.JWKSURL == "") {
+			closeAll()
+			return nil, nil, fmt.Errorf("issuer %q requires exactly one of PublicKeyPath or JWKSURL", ic.Issuer)
+		}
+
+		algorithms, err := signingAlgorithms(ic.SigningAlgorithms)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("issuer %q: %w", ic.Issuer, err)
+		}
+
+		keys := &issuerKeys{keyID: ic.KeyID, algorithms: algorithms}
+		if ic.JWKSURL != "" {
+			keys.jwks = newJWKSProvider(ic.JWKSURL, config.JWKSRefreshInterval, httpClient,
+				config.Logger.With(zap.String("issuer", ic.Issuer)))
+			closers = append(closers, keys.jwks)
+		} else {
+			keys.publicKey, err = loadPublicKey(ic.PublicKeyPath)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("failed to load public key for issuer %q: %w", ic.Issuer, err)
+			}
+		}
+		issuers[ic.Issuer] = keys
+	}
+
+	return issuers, closers, nil
+}
+
+// validMethods 合併全域與各發行者允許的演算法，作為 jwt 解析的第一道過濾；實際限制由 resolveKey 依發行者檢查
+func validMethods(global []string, issuers map[string]*issuerKeys) []string {
+	methods := slices.Clone(global)
+	for _, keys := range issuers {
+		for _, alg := range keys.algorithms {
+			if !slices.Contains(methods, alg) {
+				methods = append(methods, alg)
+			}
+		}
+	}
+	return methods
+}
+
+// selectKey 依 kid 從 JWKS 或單一公鑰中選擇驗證金鑰
+func selectKey(token *jwt.Token, publicKey interface{}, jwks *jwksProvider, keyID string) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	// JWKS 模式依 kid 選擇金鑰
+	if jwks != nil {
+		if kid == "" {
+			return nil, errors.New("token has no key id")
+		}
+		return jwks.key(kid)
+	}
+
+	// 單一金鑰模式下比對 kid，避免輪替後的 token 被誤用當前金鑰驗證
+	if _, ok := token.Header["kid"]; ok && keyID != "" && kid != keyID {
+		return nil, fmt.Errorf("unexpected key id: %v", token.Header["kid"])
+	}
+	return publicKey, nil
+}
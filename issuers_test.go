@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+func TestResolveKeyPerIssuer(t *testing.T) {
+	globalKey, globalPath := newRSAKey(t)
+	rsaKey, rsaPath := newRSAKey(t)
+	edKey, edPath := newEd25519Key(t)
+
+	issuers := []IssuerConfig{
+		{Issuer: "idp-rsa", PublicKeyPath: rsaPath, KeyID: "rsa-1"},
+		{Issuer: "idp-ed", PublicKeyPath: edPath, SigningAlgorithms: []string{"EdDSA"}},
+	}
+
+	tests := []struct {
+		name      string
+		noGlobal  bool
+		issuer    string
+		method    jwt.SigningMethod
+		key       interface{}
+		kid       string
+		wantErr   error
+		wantValid bool
+	}{
+		{name: "rsa issuer with its key", issuer: "idp-rsa", method: jwt.SigningMethodRS256, key: rsaKey, wantValid: true},
+		{name: "rsa issuer with matching kid", issuer: "idp-rsa", method: jwt.SigningMethodRS256, key: rsaKey, kid: "rsa-1", wantValid: true},
+		{name: "rsa issuer with rotated kid", issuer: "idp-rsa", method: jwt.SigningMethodRS256, key: rsaKey, kid: "rsa-2", wantErr: ErrInvalidSignature},
+		{name: "rsa issuer signed with global key", issuer: "idp-rsa", method: jwt.SigningMethodRS256, key: globalKey, wantErr: ErrInvalidSignature},
+		{name: "rsa issuer with another issuer's algorithm", issuer: "idp-rsa", method: jwt.SigningMethodEdDSA, key: edKey, wantErr: ErrInvalidSignature},
+		{name: "ed issuer with its key", issuer: "idp-ed", method: jwt.SigningMethodEdDSA, key: edKey, wantValid: true},
+		{name: "ed issuer with another issuer's key", issuer: "idp-ed", method: jwt.SigningMethodRS256, key: rsaKey, wantErr: ErrInvalidSignature},
+		{name: "ed issuer signed with global key", issuer: "idp-ed", method: jwt.SigningMethodRS256, key: globalKey, wantErr: ErrInvalidSignature},
+		{name: "global issuer with global key", issuer: testIssuer, method: jwt.SigningMethodRS256, key: globalKey, wantValid: true},
+		{name: "global issuer with listed issuer's key", issuer: testIssuer, method: jwt.SigningMethodRS256, key: rsaKey, wantErr: ErrInvalidSignature},
+		{name: "global issuer with listed issuer's algorithm", issuer: testIssuer, method: jwt.SigningMethodEdDSA, key: edKey, wantErr: ErrInvalidSignature},
+		{name: "unlisted issuer with global key", issuer: "idp-unknown", method: jwt.SigningMethodRS256, key: globalKey, wantErr: ErrInvalidIssuer},
+		{name: "issuers only accepts listed issuer", noGlobal: true, issuer: "idp-rsa", method: jwt.SigningMethodRS256, key: rsaKey, wantValid: true},
+		{name: "issuers only rejects global issuer", noGlobal: true, issuer: testIssuer, method: jwt.SigningMethodRS256, key: globalKey, wantErr: ErrInvalidIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Issuers: issuers}
+			if !tt.noGlobal {
+				config.PublicKeyPath = globalPath
+			}
+			client := newTestClient(t, config)
+
+			claims := testClaims("42")
+			claims.Issuer = tt.issuer
+			var header map[string]interface{}
+			if tt.kid != "" {
+				header = map[string]interface{}{"kid": tt.kid}
+			}
+			token := signTestToken(t, tt.method, tt.key, claims, header)
+
+			_, err := client.ValidateToken(token)
+			if tt.wantValid {
+				if err != nil {
+					t.Fatalf("ValidateToken: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateToken err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewIssuerKeysRejectsInvalidConfig(t *testing.T) {
+	_, path := newRSAKey(t)
+
+	tests := []struct {
+		name    string
+		issuers []IssuerConfig
+	}{
+		{name: "empty issuer", issuers: []IssuerConfig{{PublicKeyPath: path}}},
+		{name: "duplicate issuer", issuers: []IssuerConfig{{Issuer: "a", PublicKeyPath: path}, {Issuer: "a", PublicKeyPath: path}}},
+		{name: "no key source", issuers: []IssuerConfig{{Issuer: "a"}}},
+		{name: "both key sources", issuers: []IssuerConfig{{Issuer: "a", PublicKeyPath: path, JWKSURL: "http://127.0.0.1:1/jwks"}}},
+		{name: "hmac algorithm", issuers: []IssuerConfig{{Issuer: "a", PublicKeyPath: path, SigningAlgorithms: []string{"HS256"}}}},
+		{name: "missing key file", issuers: []IssuerConfig{{Issuer: "a", PublicKeyPath: path + ".missing"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, redisClient := newTestRedis(t)
+			_, err := NewClient(&Config{Issuers: tt.issuers, RedisClient: redisClient})
+			if err == nil {
+				t.Fatal("NewClient accepted invalid Issuers config")
+			}
+		})
+	}
+}
+
+func TestNewClientErrorReleasesResources(t *testing.T) {
+	server, _ := newTestRedis(t)
+	jwks := newJWKSServer(t, testJWK(t, "kid-1"))
+
+	_, err := NewClient(&Config{
+		JWKSURL:             jwks.URL,
+		JWKSRefreshInterval: 10 * time.Millisecond,
+		RedisAddr:           server.Addr(),
+		Issuers:             []IssuerConfig{{Issuer: "a"}},
+		Logger:              zap.NewNop(),
+	})
+	if err == nil {
+		t.Fatal("NewClient accepted invalid Issuers config")
+	}
+
+	// Redis 連線與 JWKS 背景刷新都應已停止
+	deadline := time.Now().Add(time.Second)
+	for server.CurrentConnectionCount() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := server.CurrentConnectionCount(); n > 0 {
+		t.Errorf("redis connections = %d after NewClient failed, want 0", n)
+	}
+	hits := jwks.hits.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := jwks.hits.Load(); got != hits {
+		t.Errorf("JWKS fetches grew from %d to %d after NewClient failed", hits, got)
+	}
+}
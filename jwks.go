@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultJWKSRefreshInterval JWKS 預設背景刷新間隔
+	defaultJWKSRefreshInterval = 15 * time.Minute
+	// jwksMinForcedRefreshInterval 遇到未知 kid 時強制刷新的最短間隔，避免偽造 kid 造成大量請求
+	jwksMinForcedRefreshInterval = 10 * time.Second
+	// jwksInitialRetryDelay 啟動時抓取失敗的首次重試延遲
+	jwksInitialRetryDelay = time.Second
+)
+
+// ErrUnknownKeyID token 的 kid 不在 JWKS 中
+var ErrUnknownKeyID = errors.New("unknown key id")
+
+// jwk JWKS 文件中的單一金鑰
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwksDocument JWKS 文件
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwksProvider 從 JWKS 端點取得並快取公鑰，依 kid 查詢
+type jwksProvider struct {
+	url        string
+	interval   time.Duration
+	httpClient *http.Client
+	logger     *zap.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]interface{}
+	lastRefresh time.Time
+
+	refreshMu sync.Mutex // 避免同時發出多個刷新請求
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// newJWKSProvider 建立 JWKS 金鑰來源並啟動背景刷新
+// 啟動時抓取失敗不會回傳錯誤，而是在背景以指數退避重試，期間驗證會因找不到金鑰而失敗
+func newJWKSProvider(url string, interval time.Duration, httpClient *http.Client, logger *zap.Logger) *jwksProvider {
+	if interval <= 0 {
+		interval = defaultJWKSRefreshInterval
+	}
+
+	p := &jwksProvider{
+		url:        url,
+		interval:   interval,
+		httpClient: httpClient,
+		logger:     logger,
+		keys:       make(map[string]interface{}),
+		stop:       make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := p.refresh(ctx)
+	if err != nil {
+		logger.Warn("Initial JWKS fetch failed, retrying in background",
+			zap.String("url", url), zap.Error(err))
+	}
+	go p.run(err == nil)
+
+	return p
+}
+
+// run 背景刷新迴圈；尚未成功載入時以指數退避重試
+func (p *jwksProvider) run(loaded bool) {
+	delay := jwksInitialRetryDelay
+	for !loaded {
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := p.refreshWithTimeout(); err != nil {
+			if delay *= 2; delay > p.interval {
+				delay = p.interval
+			}
+			p.logger.Warn("JWKS fetch retry failed",
+				zap.String("url", p.url), zap.Duration("next_retry", delay), zap.Error(err))
+			continue
+		}
+		loaded = true
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if err := p.refreshWithTimeout(); err != nil {
+				p.logger.Warn("JWKS refresh failed, keeping cached keys",
+					zap.String("url", p.url), zap.Error(err))
+			}
+		}
+	}
+}
+
+// key 依 kid 取得公鑰；找不到時強制刷新一次（受最短間隔限制）
+func (p *jwksProvider) key(kid string) (interface{}, error) {
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+
+	if !p.refreshedRecently() {
+		if err := p.refreshForUnknownKey(kid); err != nil {
+			p.logger.Warn("JWKS refresh for unknown kid failed",
+				zap.String("kid", kid), zap.Error(err))
+		}
+		if key, ok := p.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+}
+
+// refreshedRecently 判斷距上次刷新是否未滿強制刷新的最短間隔
+func (p *jwksProvider) refreshedRecently() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return time.Since(p.lastRefresh) < jwksMinForcedRefreshInterval
+}
+
+// refreshForUnknownKey 為未知 kid 強制刷新
+// 取得 refreshMu 後重新檢查：等待期間其他請求已完成刷新（或已載入該 kid）時不再重複抓取，
+// 同時出現的多個未知 kid 只會發出一次請求
+func (p *jwksProvider) refreshForUnknownKey(kid string) error {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	if _, ok := p.lookup(kid); ok || p.refreshedRecently() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.refreshLocked(ctx)
+}
+
+// lookup 查詢快取中的金鑰
+func (p *jwksProvider) lookup(kid string) (interface{}, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// refreshWithTimeout 以固定逾時刷新金鑰
+func (p *jwksProvider) refreshWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.refresh(ctx)
+}
+
+// refresh 抓取 JWKS 文件並替換快取的金鑰
+func (p *jwksProvider) refresh(ctx context.Context) error {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	return p.refreshLocked(ctx)
+}
+
+// refreshLocked 同 refresh，呼叫端須持有 refreshMu
+func (p *jwksProvider) refreshLocked(ctx context.Context) error {
+	keys, err := fetchJWKS(ctx, p.httpClient, p.url, p.logger)
+
+	// 無論成功與否都記錄刷新時間，讓未知 kid 的強制刷新受最短間隔限制
+	p.mu.Lock()
+	p.lastRefresh = time.Now()
+	if err == nil {
+		p.keys = keys
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	p.logger.Debug("JWKS refreshed", zap.Int("key_count", len(keys)))
+	return nil
+}
+
+// fetchJWKS 抓取 JWKS 文件並解析可用於簽名驗證的金鑰（依 kid 索引）
+func fetchJWKS(ctx context.Context, httpClient *http.Client, url string, logger *zap.Logger) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			logger.Warn("Skipping unsupported JWKS key", zap.String("kid", k.Kid), zap.Error(err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+
+	return keys, nil
+}
+
+// Close 停止背景刷新
+func (p *jwksProvider) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	return nil
+}
+
+// publicKey 將 JWK 轉換為 RSA、ECDSA 或 Ed25519 公鑰
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Ed25519 key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeJWKInt 解碼 base64url 編碼的大整數
+func decodeJWKInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing value")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
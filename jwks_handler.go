@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// JWKSHandler 以標準 JWKS 文件輸出本客戶端設定的公鑰（PublicKeyPath 與 Issuers 中的靜態公鑰），
+// 讓內部工具能以此服務作為金鑰來源
+// kid 使用設定的 KeyID，未設定時為 RFC 7638 thumbprint；經由 JWKSURL 取得的金鑰請直接向上游取得，不會重複輸出
+//
+// 只輸出公鑰，但會揭露此服務信任哪些簽發者；需要時請掛在 Authenticate 與 RequirePermission 之後或僅開放於內網
+//
+//	r.GET("/.well-known/jwks.json", authClient.JWKSHandler())
+func (c *Client) JWKSHandler() gin.HandlerFunc {
+	doc := jwksDocument{Keys: []jwk{}}
+	add := func(publicKey interface{}, keyID string) {
+		key, err := newJWK(publicKey, keyID)
+		if err != nil {
+			c.logger.Warn("Skipping public key in JWKS", zap.Error(err))
+			return
+		}
+		doc.Keys = append(doc.Keys, key)
+	}
+
+	if c.publicKey != nil {
+		add(c.publicKey, c.config.KeyID)
+	}
+	for _, ic := range c.config.Issuers {
+		if keys := c.issuers[ic.Issuer]; keys != nil && keys.publicKey != nil {
+			add(keys.publicKey, keys.keyID)
+		}
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("auth: failed to encode JWKS: %v", err))
+	}
+
+	return func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// newJWK 將公鑰轉為 JWK，keyID 為空時以 RFC 7638 thumbprint 作為 kid
+func newJWK(publicKey interface{}, keyID string) (jwk, error) {
+	var key jwk
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return jwk{}, fmt.Errorf("unsupported EC key: %w", err)
+		}
+		// 未壓縮格式：0x04 || X || Y，座標長度固定
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		key = jwk{
+			Kty: "EC",
+			Crv: pub.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(point[:size]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[size:]),
+		}
+	case ed25519.PublicKey:
+		key = jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	default:
+		return jwk{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	key.Use = "sig"
+	key.Kid = keyID
+	if key.Kid == "" {
+		key.Kid = key.thumbprint()
+	}
+	return key, nil
+}
+
+// thumbprint 依 RFC 7638 計算 JWK thumbprint（必要成員依字典序排列的 SHA-256，base64url 編碼）
+func (k jwk) thumbprint() string {
+	var members string
+	switch k.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, k.E, k.Kty, k.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, k.Crv, k.Kty, k.X, k.Y)
+	default:
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, k.Crv, k.Kty, k.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// jwksServer 提供可替換內容並統計請求次數的 JWKS 端點
+type jwksServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	keys  []jwk
+	hits  atomic.Int64
+	delay time.Duration
+}
+
+func newJWKSServer(t *testing.T, keys ...jwk) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.hits.Add(1)
+		time.Sleep(s.delay)
+		s.mu.Lock()
+		doc := jwksDocument{Keys: s.keys}
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setKeys 替換端點回傳的金鑰
+func (s *jwksServer) setKeys(keys ...jwk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// testJWK 產生 RSA 金鑰並轉為指定 kid 的 JWK
+func testJWK(t *testing.T, kid string) jwk {
+	t.Helper()
+	key, _ := newRSAKey(t)
+	k, err := newJWK(&key.PublicKey, kid)
+	if err != nil {
+		t.Fatalf("newJWK: %v", err)
+	}
+	return k
+}
+
+// newTestJWKSProvider 建立 JWKS 金鑰來源，並將上次刷新時間往前調，允許立即強制刷新
+func newTestJWKSProvider(t *testing.T, url string) *jwksProvider {
+	t.Helper()
+	p := newJWKSProvider(url, time.Hour, &http.Client{Timeout: 5 * time.Second}, zap.NewNop())
+	t.Cleanup(func() { p.Close() })
+
+	p.mu.Lock()
+	p.lastRefresh = time.Now().Add(-time.Minute)
+	p.mu.Unlock()
+	return p
+}
+
+func TestJWKSProviderRefreshesForRotatedKey(t *testing.T) {
+	server := newJWKSServer(t, testJWK(t, "k1"))
+	p := newTestJWKSProvider(t, server.URL)
+
+	server.setKeys(testJWK(t, "k1"), testJWK(t, "k2"))
+	if _, err := p.key("k2"); err != nil {
+		t.Fatalf("key(k2) after rotation: %v", err)
+	}
+	if hits := server.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetches = %d, want 2", hits)
+	}
+}
+
+func TestJWKSProviderRateLimitsForcedRefresh(t *testing.T) {
+	server := newJWKSServer(t, testJWK(t, "k1"))
+	p := newTestJWKSProvider(t, server.URL)
+
+	if _, err := p.key("unknown-1"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("key(unknown-1) err = %v, want ErrUnknownKeyID", err)
+	}
+	if _, err := p.key("unknown-2"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("key(unknown-2) err = %v, want ErrUnknownKeyID", err)
+	}
+	if hits := server.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetches = %d, want 2 (initial plus one forced refresh)", hits)
+	}
+}
+
+func TestJWKSProviderConcurrentUnknownKeysShareOneRefresh(t *testing.T) {
+	server := newJWKSServer(t, testJWK(t, "k1"))
+	p := newTestJWKSProvider(t, server.URL)
+	server.delay = 20 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = p.key(fmt.Sprintf("forged-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if hits := server.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetches = %d, want 2 (initial plus one forced refresh)", hits)
+	}
+}
+
+func TestValidationSlotReleasedDuringJWKSFetch(t *testing.T) {
+	key, _ := newRSAKey(t)
+	known, err := newJWK(&key.PublicKey, "k1")
+	if err != nil {
+		t.Fatalf("newJWK: %v", err)
+	}
+	server := newJWKSServer(t, known)
+	client := newTestClient(t, &Config{JWKSURL: server.URL, MaxConcurrentValidations: 1})
+
+	client.jwks.mu.Lock()
+	client.jwks.lastRefresh = time.Now().Add(-time.Minute)
+	client.jwks.mu.Unlock()
+	server.delay = 300 * time.Millisecond
+
+	// 未知 kid 觸發強制刷新，刷新期間不應佔用唯一的驗證名額
+	unknown := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("1"), map[string]interface{}{"kid": "rotated"})
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ValidateToken(unknown)
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.hits.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("forced JWKS refresh did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	token := signTestToken(t, jwt.SigningMethodRS256, key, testClaims("42"), map[string]interface{}{"kid": "k1"})
+	if _, err := client.ValidateToken(token); err != nil {
+		t.Fatalf("ValidateToken during JWKS fetch: %v", err)
+	}
+	if got := client.InFlightValidations(); got != 0 {
+		t.Errorf("InFlightValidations() = %d, want 0", got)
+	}
+
+	if err := <-done; !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("unknown kid err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// loggerGinKey 請求範圍 logger 在 gin.Context 中的 key
+const loggerGinKey = "logger"
+
+// loggerContextKey 請求範圍 logger 在 context.Context 中的 key
+type loggerContextKey struct{}
+
+// requestIDContextKey 請求 ID 在 context.Context 中的 key
+type requestIDContextKey struct{}
+
+// requestIDHeader 請求 ID 標頭
+const requestIDHeader = "X-Request-ID"
+
+// ContextWithLogger 回傳帶有 logger 的 context
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// SetRequestLogger 將請求範圍的 logger 放入 gin.Context 與其 request context
+// 之後的處理器與以 c.Request.Context() 呼叫的下游程式碼都能透過 LoggerFromContext 取得
+func SetRequestLogger(c *gin.Context, logger *zap.Logger) {
+	c.Set(loggerGinKey, logger)
+	c.Request = c.Request.WithContext(ContextWithLogger(c.Request.Context(), logger))
+}
+
+// LoggerFromContext 取得請求範圍的 logger（已帶有 request_id、user_id 等關聯欄位）
+// ctx 可為 *gin.Context 或一般 context；找不到時回傳 zap.L()
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if c, ok := ctx.(*gin.Context); ok {
+		if logger, ok := c.Value(loggerGinKey).(*zap.Logger); ok {
+			return logger
+		}
+		if c.Request == nil {
+			return zap.L()
+		}
+		ctx = c.Request.Context()
+	}
+
+	if logger, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// ContextWithRequestID 回傳帶有請求 ID 的 context，ValidateTokenWithDynamicAuth 等會將其加入日誌欄位
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext 取得請求 ID，找不到時回傳空字串
+// ctx 為 *gin.Context 時依序讀取 RequestID 中介軟體設置的值、request context 與 X-Request-ID 標頭
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		if requestID := c.GetString(ContextKeyRequestID); requestID != "" {
+			return requestID
+		}
+		if c.Request == nil {
+			return ""
+		}
+		if requestID := RequestIDFromContext(c.Request.Context()); requestID != "" {
+			return requestID
+		}
+		return c.GetHeader(requestIDHeader)
+	}
+
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// requestContext 回傳傳給 Client 的 request context，並確保帶有請求 ID（無論由標頭傳入或由 RequestID 中介軟體產生）
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	if requestID := RequestIDFromContext(c); requestID != "" {
+		return ContextWithRequestID(ctx, requestID)
+	}
+	return ctx
+}
+
+// setAuthenticatedLogger 以既有的請求 logger（或中介軟體 logger）加上用戶欄位後放回 context
+func (m *GinMiddleware) setAuthenticatedLogger(c *gin.Context, claims *Claims) {
+	logger, ok := c.Value(loggerGinKey).(*zap.Logger)
+	if !ok {
+		logger = m.logger
+		if requestID := c.GetString(ContextKeyRequestID); requestID != "" {
+			logger = logger.With(zap.String("request_id", requestID))
+		}
+	}
+
+	SetRequestLogger(c, logger.With(
+		zap.String("user_id", claims.UserID),
+		zap.String("username", claims.Username)))
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats 記憶體快取的命中統計
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // 因容量不足而淘汰的項目數
+	Size      int    `json:"size"`
+}
+
+// lruCache 具 TTL 的 LRU 快取，可安全地併發使用
+type lruCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[K]*list.Element
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// lruEntry 快取項目
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// newLRUCache 建立容量為 capacity 的 LRU 快取
+func newLRUCache[K comparable, V any](capacity int) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// Get 取得未過期的項目，過期項目會被移除
+func (l *lruCache[K, V]) Get(key K) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var zero V
+	elem, ok := l.items[key]
+	if !ok {
+		l.misses.Add(1)
+		return zero, false
+	}
+
+	entry := elem.Value.(*lruEntry[K, V])
+	if !time.Now().Before(entry.expiresAt) {
+		l.removeElement(elem)
+		l.misses.Add(1)
+		return zero, false
+	}
+
+	l.ll.MoveToFront(elem)
+	l.hits.Add(1)
+	return entry.value, true
+}
+
+// Set 寫入項目並於 expiresAt 失效，超出容量時淘汰最久未使用的項目
+func (l *lruCache[K, V]) Set(key K, value V, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.ll.MoveToFront(elem)
+		return
+	}
+
+	l.items[key] = l.ll.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for l.capacity > 0 && l.ll.Len() > l.capacity {
+		l.removeElement(l.ll.Back())
+		l.evictions.Add(1)
+	}
+}
+
+// DeleteFunc 移除所有符合條件的項目
+func (l *lruCache[K, V]) DeleteFunc(match func(key K, value V) bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for elem := l.ll.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*lruEntry[K, V])
+		if match(entry.key, entry.value) {
+			l.removeElement(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// Stats 回傳命中統計
+func (l *lruCache[K, V]) Stats() CacheStats {
+	l.mu.Lock()
+	size := l.ll.Len()
+	l.mu.Unlock()
+
+	return CacheStats{
+		Hits:      l.hits.Load(),
+		Misses:    l.misses.Load(),
+		Evictions: l.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// removeElement 移除項目（呼叫端需持有鎖）
+func (l *lruCache[K, V]) removeElement(elem *list.Element) {
+	l.ll.Remove(elem)
+	delete(l.items, elem.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyAPIVersion 協商後的 API 版本在 gin.Context 中的 key
+const ContextKeyAPIVersion = "api_version"
+
+// APIVersionConfig API 版本檢查設定
+type APIVersionConfig struct {
+	// Supported 支援的版本（如 v1、v2），比對時不分大小寫
+	Supported []string
+	// Default 請求未帶版本標頭時使用的版本，預設為 Supported 的第一個
+	Default string
+	// Header 版本標頭名稱，預設 Accept-Version
+	Header string
+}
+
+// RequireAPIVersion 檢查 Accept-Version 標頭是否為支援的版本，不支援時回應 406
+// 未帶標頭時使用 supported 的第一個版本；協商結果可由 GetAPIVersion 取得
+func RequireAPIVersion(supported []string) gin.HandlerFunc {
+	return RequireAPIVersionWithConfig(APIVersionConfig{Supported: supported})
+}
+
+// RequireAPIVersionWithConfig 依設定建立 API 版本檢查中間件
+func RequireAPIVersionWithConfig(config APIVersionConfig) gin.HandlerFunc {
+	if len(config.Supported) == 0 {
+		panic("middleware: RequireAPIVersion requires at least one supported version")
+	}
+	if config.Default == "" {
+		config.Default = config.Supported[0]
+	}
+	if config.Header == "" {
+		config.Header = "Accept-Version"
+	}
+	supportedList := strings.Join(config.Supported, ", ")
+
+	return func(c *gin.Context) {
+		requested := strings.TrimSpace(c.GetHeader(config.Header))
+		if requested == "" {
+			c.Set(ContextKeyAPIVersion, config.Default)
+			c.Next()
+			return
+		}
+
+		for _, version := range config.Supported {
+			if strings.EqualFold(requested, version) {
+				c.Set(ContextKeyAPIVersion, version)
+				c.Next()
+				return
+			}
+		}
+
+		response.Error(c, http.StatusNotAcceptable, "NOT_ACCEPTABLE",
+			"Unsupported API version, supported versions: "+supportedList,
+			gin.H{"requested_version": requested, "supported_versions": config.Supported})
+		c.Abort()
+	}
+}
+
+// GetAPIVersion 取得 RequireAPIVersion 協商後的 API 版本
+func GetAPIVersion(c *gin.Context) (string, bool) {
+	version, ok := c.Get(ContextKeyAPIVersion)
+	if !ok {
+		return "", false
+	}
+	s, ok := version.(string)
+	return s, ok
+}
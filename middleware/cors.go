@@ -1,35 +1,69 @@
 package middleware
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
+// CORSConfig 跨域中間件設定
+type CORSConfig struct {
+	// AllowedOrigins 允許的來源，支援三種寫法：
+	//   - 完整來源，如 https://app.example.com
+	//   - 子網域萬用字元，如 *.example.com（http 與 https）或 https://*.example.com；* 匹配一或多層子網域，不含 example.com 本身，可帶任意連接埠
+	//   - *，允許任何來源（回應 Access-Control-Allow-Origin: *，不會帶上 credentials）
+	AllowedOrigins []string
+	// AllowedOriginPatterns 以正規表示式描述允許的來源（完整比對、不分大小寫），如 `https://pr-\d+\.preview\.example\.com`
+	AllowedOriginPatterns []string
+	// AllowCredentials 是否回應 Access-Control-Allow-Credentials: true
+	// 只會對明確允許的來源回應；* 不會被當成允許任意來源帶 credentials，避免任何網站都能以用戶身份呼叫 API
+	AllowCredentials bool
+}
+
 // CORS 統一的跨域中間件
-// 提供統一的 CORS 設定，支持多域名配置
+// 提供統一的 CORS 設定，支持多域名配置（含 *.example.com 子網域萬用字元），並允許帶 credentials
 func CORS(allowedOrigins []string) gin.HandlerFunc {
+	return CORSWithConfig(CORSConfig{
+		AllowedOrigins:   allowedOrigins,
+		AllowCredentials: true,
+	})
+}
+
+// CORSWithConfig 依設定建立跨域中間件，正規表示式無效時 panic
+func CORSWithConfig(config CORSConfig) gin.HandlerFunc {
+	matcher := newOriginMatcher(config)
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-		
-		// 檢查是否允許該來源
+
+		// 回應內容依 Origin 而不同，避免快取將某來源的回應給其他來源
+		c.Header("Vary", "Origin")
+
+		// 檢查是否允許該來源，僅反射明確允許的來源
+		// 沒有 Origin（非跨域請求）或來源不被允許時不設定任何 CORS 標頭
 		allowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if allowedOrigin == "*" || allowedOrigin == origin {
+		if origin != "" {
+			if matcher.matches(origin) {
 				allowed = true
-				break
+				c.Header("Access-Control-Allow-Origin", origin)
+				if config.AllowCredentials {
+					c.Header("Access-Control-Allow-Credentials", "true")
+				}
+			} else if matcher.any {
+				allowed = true
+				c.Header("Access-Control-Allow-Origin", "*")
 			}
 		}
 
+		// 設定 CORS Headers
 		if allowed {
-			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
+			c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
+			c.Header("Access-Control-Max-Age", "86400")
 		}
 
-		// 設定 CORS Headers
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "86400")
-
 		// 處理 OPTIONS 預檢請求
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -39,3 +73,63 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// originMatcher 比對請求來源
+type originMatcher struct {
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+	any      bool // 設定了 *
+}
+
+// newOriginMatcher 編譯來源設定：子網域萬用字元轉為正規表示式，所有正規表示式皆完整比對
+// 比對時來源一律轉為小寫，自訂正規表示式因此以 (?i) 編譯，避免含大寫字母的寫法永遠不相符
+func newOriginMatcher(config CORSConfig) *originMatcher {
+	m := &originMatcher{exact: make(map[string]bool)}
+
+	for _, origin := range config.AllowedOrigins {
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "*"):
+			m.patterns = append(m.patterns, wildcardOriginPattern(origin))
+		default:
+			m.exact[strings.ToLower(strings.TrimRight(origin, "/"))] = true
+		}
+	}
+	for _, pattern := range config.AllowedOriginPatterns {
+		m.patterns = append(m.patterns, regexp.MustCompile("(?i)^(?:"+pattern+")$"))
+	}
+
+	return m
+}
+
+// wildcardOriginPattern 將 *.example.com 或 https://*.example.com 轉為正規表示式
+func wildcardOriginPattern(origin string) *regexp.Regexp {
+	scheme := "https?"
+	host := origin
+	if s, rest, found := strings.Cut(origin, "://"); found {
+		scheme = regexp.QuoteMeta(strings.ToLower(s))
+		host = rest
+	}
+
+	suffix, ok := strings.CutPrefix(strings.ToLower(host), "*.")
+	if !ok || suffix == "" || strings.Contains(suffix, "*") {
+		panic("middleware: invalid CORS wildcard origin " + origin + ", expected *.example.com")
+	}
+
+	return regexp.MustCompile(`^` + scheme + `://([a-z0-9-]+\.)+` + regexp.QuoteMeta(suffix) + `(:\d+)?$`)
+}
+
+// matches 判斷來源是否明確允許（不含 *）
+func (m *originMatcher) matches(origin string) bool {
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
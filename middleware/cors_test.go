@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveCORS 以 CORS 中間件處理單一請求，回傳回應與 handler 是否被呼叫
+func serveCORS(handler gin.HandlerFunc, method, origin string) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	router := gin.New()
+	router.Use(handler)
+	router.Handle(method, "/resource", func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(method, "/resource", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, reached
+}
+
+func TestCORSOrigins(t *testing.T) {
+	config := CORSConfig{
+		AllowedOrigins:        []string{"https://app.example.com/", "*.example.org", "https://*.example.net"},
+		AllowedOriginPatterns: []string{`https://pr-\d+\.preview\.example\.com`, `https://Admin\.Example\.io`},
+		AllowCredentials:      true,
+	}
+
+	tests := []struct {
+		name        string
+		origin      string
+		wantOrigin  string
+		wantAllowed bool
+	}{
+		{name: "exact origin", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantAllowed: true},
+		{name: "exact origin is case insensitive", origin: "https://APP.example.com", wantOrigin: "https://APP.example.com", wantAllowed: true},
+		{name: "exact origin other scheme", origin: "http://app.example.com"},
+		{name: "wildcard subdomain", origin: "https://api.example.org", wantOrigin: "https://api.example.org", wantAllowed: true},
+		{name: "wildcard nested subdomain over http", origin: "http://a.b.example.org", wantOrigin: "http://a.b.example.org", wantAllowed: true},
+		{name: "wildcard excludes apex", origin: "https://example.org"},
+		{name: "wildcard suffix lookalike", origin: "https://evilexample.org"},
+		{name: "wildcard with scheme", origin: "https://api.example.net", wantOrigin: "https://api.example.net", wantAllowed: true},
+		{name: "wildcard with scheme rejects http", origin: "http://api.example.net"},
+		{name: "wildcard with port", origin: "https://a.example.net:8443", wantOrigin: "https://a.example.net:8443", wantAllowed: true},
+		{name: "wildcard rejects non-numeric port", origin: "https://a.example.net:evil"},
+		{name: "pattern", origin: "https://pr-42.preview.example.com", wantOrigin: "https://pr-42.preview.example.com", wantAllowed: true},
+		{name: "pattern is anchored", origin: "https://pr-42.preview.example.com.evil.io"},
+		{name: "uppercase pattern", origin: "https://admin.example.io", wantOrigin: "https://admin.example.io", wantAllowed: true},
+		{name: "uppercase pattern mixed case origin", origin: "https://ADMIN.example.io", wantOrigin: "https://ADMIN.example.io", wantAllowed: true},
+		{name: "unknown origin", origin: "https://evil.io"},
+		{name: "no origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reached := serveCORS(CORSWithConfig(config), http.MethodGet, tt.origin)
+			if !reached || w.Code != http.StatusOK {
+				t.Fatalf("status = %d, reached = %v; CORS must not block simple requests", w.Code, reached)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+
+			wantCredentials, wantMethods := "", ""
+			if tt.wantAllowed {
+				wantCredentials = "true"
+				wantMethods = "GET, POST, PUT, DELETE, OPTIONS, PATCH"
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCredentials)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, wantMethods)
+			}
+		})
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	handler := CORSWithConfig(CORSConfig{AllowedOrigins: []string{"*", "https://app.example.com"}, AllowCredentials: true})
+
+	tests := []struct {
+		name            string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{name: "listed origin keeps credentials", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCredentials: "true"},
+		{name: "other origin without credentials", origin: "https://evil.io", wantOrigin: "*"},
+		{name: "no origin", wantOrigin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := serveCORS(handler, http.MethodGet, tt.origin)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := CORS([]string{"https://app.example.com"})
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantOrigin string
+	}{
+		{name: "allowed origin", origin: "https://app.example.com", wantOrigin: "https://app.example.com"},
+		{name: "disallowed origin", origin: "https://evil.io"},
+		{name: "no origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reached := serveCORS(handler, http.MethodOptions, tt.origin)
+			if reached {
+				t.Error("preflight reached the handler")
+			}
+			if w.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want 204", w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); (got != "") != (tt.wantOrigin != "") {
+				t.Errorf("Access-Control-Max-Age = %q for origin %q", got, tt.origin)
+			}
+		})
+	}
+}
+
+func TestCORSInvalidWildcardPanics(t *testing.T) {
+	for _, origin := range []string{"https://api.*.example.com", "*.", "*.*.example.com"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CORSWithConfig(%q) did not panic", origin)
+				}
+			}()
+			CORSWithConfig(CORSConfig{AllowedOrigins: []string{origin}})
+		}()
+	}
+}
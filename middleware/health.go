@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthChecker 可回報依賴健康狀態的元件，*auth.Client 即實作此介面
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) *auth.HealthReport
+}
+
+// Ping 存活檢查（liveness），不檢查任何依賴，一律回應 200
+//
+//	r.GET("/ping", middleware.Ping())
+func Ping() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		response.Success(c, gin.H{"status": "ok"})
+	}
+}
+
+// Ready 就緒檢查（readiness），回報 checker 各依賴（Redis、Auth 服務等）的狀態
+// 全部正常時回應 200，任一失敗時回應 503，details 為各依賴明細
+// 錯誤訊息可能包含內部位址，請勿對外公開此端點
+//
+//	r.GET("/health", middleware.Ready(authClient))
+func Ready(checker HealthChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.CheckHealth(c.Request.Context())
+
+		c.Header("Cache-Control", "no-store")
+		if !report.Healthy {
+			response.Error(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "One or more dependencies are unhealthy", report)
+			return
+		}
+		response.Success(c, report)
+	}
+}
@@ -1,50 +1,155 @@
 package middleware
 
 import (
+	"time"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// LoggerConfig 日誌中間件設定
+type LoggerConfig struct {
+	// UseRouteTemplate 以路由模板（如 /users/:id）作為 path 欄位，
+	// 原始路徑改記錄於 raw_path，避免參數化路由造成高基數的日誌維度
+	UseRouteTemplate bool
+
+	// IPResolver 依信任代理解析 client_ip 欄位，通常傳入 authClient.IPResolver()（nil 表示使用 c.ClientIP()）
+	IPResolver *auth.ClientIPResolver
+
+	// LogRequestBody、LogResponseBody 是否記錄請求/響應主體（request_body、response_body 欄位），僅供除錯時開啟
+	// 只記錄 JSON 與表單主體並遮蔽機敏欄位；超過 MaxBodySize、無法解析或其他類型的主體只記錄說明，不記錄內容
+	LogRequestBody  bool
+	LogResponseBody bool
+	// MaxBodySize 記錄主體的上限（bytes），預設 4096
+	MaxBodySize int
+	// RedactFields 額外遮蔽為 *** 的欄位名稱（不分大小寫，含巢狀欄位）
+	// password、token、access_token、refresh_token、id_token、secret、client_secret、api_key、authorization 一律遮蔽
+	RedactFields []string
+
+	// SkipPaths 不記錄 2xx 響應的路徑，如健康檢查；非 2xx 響應仍會記錄
+	// 路徑可為原始路徑（/healthz）或路由模板（/metrics/:name），需完整相符
+	SkipPaths []string
+	// SamplePaths 抽樣記錄 2xx 響應的路徑，每 SampleRate 筆記錄 1 筆；非 2xx 響應一律記錄
+	SamplePaths []string
+	// SampleRate SamplePaths 的抽樣間隔，小於等於 1 時全部記錄
+	SampleRate int
+}
+
 // Logger 統一的日誌中間件
 // 提供結構化日誌記錄，包含請求ID、用戶信息等
 func Logger(logger *zap.Logger) gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+	return LoggerWithConfig(logger, LoggerConfig{})
+}
+
+// LoggerWithConfig 依設定建立日誌中間件
+func LoggerWithConfig(logger *zap.Logger, config LoggerConfig) gin.HandlerFunc {
+	bodies := newBodyLogger(config)
+	sampler := newLogSampler(config)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path = path + "?" + raw
+		}
+
+		var requestBody []byte
+		var requestTruncated bool
+		if config.LogRequestBody {
+			requestBody, requestTruncated = bodies.captureRequest(c)
+		}
+		var responseWriter *bodyCaptureWriter
+		if config.LogResponseBody {
+			responseWriter = &bodyCaptureWriter{ResponseWriter: c.Writer, maxSize: bodies.maxSize}
+			c.Writer = responseWriter
+		}
+
+		// 提供帶 request_id 的請求 logger，Authenticate 會再加上用戶欄位
+		requestLogger := logger
+		if requestID := requestIDFromContext(c); requestID != "" {
+			requestLogger = logger.With(zap.String("request_id", requestID))
+		}
+		auth.SetRequestLogger(c, requestLogger)
+
+		c.Next()
+
+		if !sampler.shouldLog(c) {
+			return
+		}
+
+		timestamp := time.Now()
 		fields := []zapcore.Field{
-			zap.String("method", param.Method),
-			zap.String("path", param.Path),
-			zap.String("protocol", param.Request.Proto),
-			zap.Int("status", param.StatusCode),
-			zap.Duration("latency", param.Latency),
-			zap.String("client_ip", param.ClientIP),
-			zap.String("user_agent", param.Request.UserAgent()),
-			zap.Time("timestamp", param.TimeStamp),
+			zap.String("method", c.Request.Method),
 		}
 
-		if param.ErrorMessage != "" {
-			fields = append(fields, zap.String("error", param.ErrorMessage))
+		// 路由模板僅在有匹配的路由時可用，否則退回原始路徑
+		if route := c.FullPath(); config.UseRouteTemplate && route != "" {
+			fields = append(fields,
+				zap.String("path", route),
+				zap.String("raw_path", path))
+		} else {
+			fields = append(fields, zap.String("path", path))
+		}
+
+		fields = append(fields,
+			zap.String("protocol", c.Request.Proto),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", timestamp.Sub(start)),
+			zap.String("client_ip", config.IPResolver.ClientIP(c)),
+			zap.String("user_agent", c.Request.UserAgent()),
+			zap.Time("timestamp", timestamp),
+		)
+
+		if errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String(); errorMessage != "" {
+			fields = append(fields, zap.String("error", errorMessage))
+		}
+
+		if body := bodies.format(requestBody, requestTruncated, c.ContentType()); body != "" {
+			fields = append(fields, zap.String("request_body", body))
+		}
+		if responseWriter != nil {
+			body := bodies.format(responseWriter.body.Bytes(), responseWriter.truncated, responseWriter.Header().Get("Content-Type"))
+			if body != "" {
+				fields = append(fields, zap.String("response_body", body))
+			}
 		}
 
 		// Add request ID if available
-		if requestID := param.Request.Header.Get("X-Request-ID"); requestID != "" {
+		if requestID := c.Request.Header.Get("X-Request-ID"); requestID != "" {
 			fields = append(fields, zap.String("request_id", requestID))
 		}
 
 		// Add user information if available
-		if userID := param.Request.Header.Get("X-User-ID"); userID != "" {
+		// 優先使用 Authenticate 設置於上下文的用戶，未驗證的請求才退回 X-User-ID 標頭
+		userID, ok := auth.GetUserID(c)
+		if !ok {
+			userID = c.Request.Header.Get("X-User-ID")
+		}
+		if userID != "" {
 			fields = append(fields, zap.String("user_id", userID))
 		}
+		if username, ok := auth.GetUsername(c); ok && username != "" {
+			fields = append(fields, zap.String("username", username))
+		}
 
 		// Log based on status code
-		if param.StatusCode >= 500 {
+		status := c.Writer.Status()
+		if status >= 500 {
 			logger.Error("HTTP Request", fields...)
-		} else if param.StatusCode >= 400 {
+		} else if status >= 400 {
 			logger.Warn("HTTP Request", fields...)
 		} else {
 			logger.Info("HTTP Request", fields...)
 		}
+	}
+}
 
-		return ""
-
-	})
+// requestIDFromContext 取得 RequestID 中間件設置的請求ID，未設置時讀取標頭
+func requestIDFromContext(c *gin.Context) string {
+	if requestID := c.GetString("request_id"); requestID != "" {
+		return requestID
+	}
+	return c.GetHeader("X-Request-ID")
 }
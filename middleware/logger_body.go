@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxLoggedBodySize 記錄請求/響應主體的預設上限（bytes）
+const defaultMaxLoggedBodySize = 4096
+
+// redactedValue 遮蔽後的欄位值
+const redactedValue = "***"
+
+// defaultRedactFields 一律遮蔽的欄位名稱（不分大小寫），LoggerConfig.RedactFields 只能再增加
+var defaultRedactFields = []string{
+	"password", "token", "access_token", "refresh_token", "id_token",
+	"secret", "client_secret", "api_key", "authorization",
+}
+
+// bodyLogger 擷取並遮蔽請求/響應主體
+type bodyLogger struct {
+	maxSize int
+	redact  map[string]bool
+}
+
+// newBodyLogger 依設定建立主體擷取器
+func newBodyLogger(config LoggerConfig) *bodyLogger {
+	b := &bodyLogger{
+		maxSize: config.MaxBodySize,
+		redact:  make(map[string]bool),
+	}
+	if b.maxSize <= 0 {
+		b.maxSize = defaultMaxLoggedBodySize
+	}
+	for _, field := range append(defaultRedactFields, config.RedactFields...) {
+		b.redact[strings.ToLower(field)] = true
+	}
+	return b
+}
+
+// captureRequest 讀取請求主體的前 maxSize bytes，並將完整主體還原給後續 handler
+// 只預先讀取上限內的內容，大型上傳不會整個載入記憶體
+func (b *bodyLogger) captureRequest(c *gin.Context) (body []byte, truncated bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+
+	original := c.Request.Body
+	prefix, _ := io.ReadAll(io.LimitReader(original, int64(b.maxSize)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), original), original}
+
+	if len(prefix) > b.maxSize {
+		return prefix[:b.maxSize], true
+	}
+	return prefix, false
+}
+
+// format 依內容類型遮蔽主體後回傳記錄用字串
+// 只記錄可可靠遮蔽的 JSON 與表單主體；被截斷、無法解析或其他類型的主體以說明取代，避免洩漏機敏資料
+func (b *bodyLogger) format(body []byte, truncated bool, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	isForm := mediaType == "application/x-www-form-urlencoded"
+	if !isJSON && !isForm {
+		if mediaType == "" {
+			mediaType = "unknown"
+		}
+		return fmt.Sprintf("[%s body omitted]", mediaType)
+	}
+	if truncated {
+		return fmt.Sprintf("[body omitted: larger than %d bytes]", b.maxSize)
+	}
+
+	if isForm {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[invalid form body omitted]"
+		}
+		for key := range values {
+			if b.redact[strings.ToLower(key)] {
+				values[key] = []string{redactedValue}
+			}
+		}
+		return values.Encode()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return "[invalid JSON body omitted]"
+	}
+	redacted, err := json.Marshal(b.redactJSON(data))
+	if err != nil {
+		return "[invalid JSON body omitted]"
+	}
+	return string(redacted)
+}
+
+// redactJSON 遞迴遮蔽名稱符合的欄位，欄位值不論型別（含物件與陣列）皆整個取代
+func (b *bodyLogger) redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if b.redact[strings.ToLower(key)] {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = b.redactJSON(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = b.redactJSON(item)
+		}
+	}
+	return value
+}
+
+// bodyCaptureWriter 寫入響應的同時保留前 maxSize bytes 供記錄
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	maxSize   int
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.capture(data[:n])
+	return n, err
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.capture([]byte(s[:n]))
+	return n, err
+}
+
+// capture 保留未超過上限的部分
+func (w *bodyCaptureWriter) capture(data []byte) {
+	remaining := w.maxSize - w.body.Len()
+	if len(data) > remaining {
+		data = data[:remaining]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logSampler 決定成功請求是否記錄：略過 SkipPaths，SamplePaths 每 SampleRate 筆記錄 1 筆
+type logSampler struct {
+	skip     map[string]bool
+	counters map[string]*atomic.Uint64
+	rate     uint64
+}
+
+// newLogSampler 依設定建立抽樣器，未設定任何路徑時回傳 nil
+func newLogSampler(config LoggerConfig) *logSampler {
+	if len(config.SkipPaths) == 0 && (len(config.SamplePaths) == 0 || config.SampleRate <= 1) {
+		return nil
+	}
+
+	s := &logSampler{
+		skip:     make(map[string]bool, len(config.SkipPaths)),
+		counters: make(map[string]*atomic.Uint64, len(config.SamplePaths)),
+		rate:     uint64(config.SampleRate),
+	}
+	for _, path := range config.SkipPaths {
+		s.skip[path] = true
+	}
+	if config.SampleRate > 1 {
+		for _, path := range config.SamplePaths {
+			s.counters[path] = new(atomic.Uint64)
+		}
+	}
+	return s
+}
+
+// shouldLog 判斷請求是否記錄；非 2xx 響應一律記錄
+// 路徑可設定為原始路徑（/healthz）或路由模板（/metrics/:name）
+func (s *logSampler) shouldLog(c *gin.Context) bool {
+	if s == nil {
+		return true
+	}
+	if status := c.Writer.Status(); status < 200 || status >= 300 {
+		return true
+	}
+
+	for _, path := range []string{c.Request.URL.Path, c.FullPath()} {
+		if path == "" {
+			continue
+		}
+		if s.skip[path] {
+			return false
+		}
+		if counter, ok := s.counters[path]; ok {
+			// 每個路徑的第 1、N+1、2N+1… 筆記錄
+			return (counter.Add(1)-1)%s.rate == 0
+		}
+	}
+	return true
+}
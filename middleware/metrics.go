@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute 沒有匹配路由（如 404）時使用的 path 標籤，避免任意路徑造成高基數
+const unmatchedRoute = "unmatched"
+
+// auth_validations_total 的 source 標籤
+const (
+	validationSourceCache = "cache"
+	validationSourceFresh = "fresh"
+)
+
+// MetricsConfig Prometheus 指標中間件設定
+type MetricsConfig struct {
+	// Namespace 指標名稱前綴，如 cdn 會產生 cdn_http_requests_total
+	Namespace string
+	// Registerer 註冊指標的位置，預設 prometheus.DefaultRegisterer（即 Handler 輸出的內容）
+	Registerer prometheus.Registerer
+	// Buckets 延遲直方圖的區間（秒），預設 prometheus.DefBuckets
+	Buckets []float64
+	// Validator 設定時另輸出 auth_validations_in_flight，即進行中的 JWT 簽名驗證數（見 auth.Config.MaxConcurrentValidations）
+	Validator InFlightValidator
+	// RateLimiter 設定時另輸出 rate_limiter_active_backend，目前使用中的後端（redis 或 memory）為 1、另一個為 0
+	RateLimiter *RateLimiter
+}
+
+// InFlightValidator 可回報進行中 JWT 驗證數的驗證客戶端，*auth.Client 已實作
+type InFlightValidator interface {
+	InFlightValidations() int64
+}
+
+// httpMetrics 請求指標
+type httpMetrics struct {
+	requests    *prometheus.CounterVec
+	inFlight    *prometheus.GaugeVec
+	duration    *prometheus.HistogramVec
+	validations *prometheus.CounterVec
+}
+
+// Metrics 記錄請求數、處理中請求數與延遲的 Prometheus 中間件
+// 標籤為 method、path（路由模板，如 /users/:id）與 status
+// 經過 Authenticate 或 OptionalAuth 驗證的請求另記錄 auth_validations_total，source 標籤為 cache 或 fresh，
+// 可用於評估 ValidationCacheTTL 的效益
+func Metrics() gin.HandlerFunc {
+	return MetricsWithConfig(MetricsConfig{})
+}
+
+// MetricsWithConfig 依設定建立 Prometheus 指標中間件
+// 相同設定重複建立時沿用已註冊的指標，不會 panic
+func MetricsWithConfig(config MetricsConfig) gin.HandlerFunc {
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	metrics := &httpMetrics{
+		requests: registerCollector(config.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, []string{"method", "path", "status"})),
+		inFlight: registerCollector(config.Registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}, []string{"method", "path"})),
+		duration: registerCollector(config.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   config.Buckets,
+		}, []string{"method", "path", "status"})),
+		validations: registerCollector(config.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "auth_validations_total",
+			Help:      "Total number of token validations by source (cache or fresh).",
+		}, []string{"path", "source"})),
+	}
+
+	if validator := config.Validator; validator != nil {
+		registerCollector(config.Registerer, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Name:      "auth_validations_in_flight",
+			Help:      "Number of JWT signature verifications currently in progress.",
+		}, func() float64 {
+			return float64(validator.InFlightValidations())
+		}))
+	}
+
+	if limiter := config.RateLimiter; limiter != nil {
+		for _, backend := range []string{RateLimitBackendRedis, RateLimitBackendMemory} {
+			backend := backend
+			registerCollector(config.Registerer, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace:   config.Namespace,
+				Name:        "rate_limiter_active_backend",
+				Help:        "Whether the rate limiter backend is currently in use (1) or not (0).",
+				ConstLabels: prometheus.Labels{"backend": backend},
+			}, func() float64 {
+				if limiter.ActiveBackend() == backend {
+					return 1
+				}
+				return 0
+			}))
+		}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		method := c.Request.Method
+		path := c.FullPath()
+		if path == "" {
+			path = unmatchedRoute
+		}
+
+		inFlight := metrics.inFlight.WithLabelValues(method, path)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.requests.WithLabelValues(method, path, status).Inc()
+		metrics.duration.WithLabelValues(method, path, status).Observe(time.Since(start).Seconds())
+
+		if value, ok := c.Get(auth.ContextKeyValidatedFromCache); ok {
+			source := validationSourceFresh
+			if fromCache, _ := value.(bool); fromCache {
+				source = validationSourceCache
+			}
+			metrics.validations.WithLabelValues(path, source).Inc()
+		}
+	}
+}
+
+// Handler 回傳 /metrics 抓取端點，輸出 prometheus.DefaultRegisterer 中的指標
+// 使用自訂 Registerer 時請改用 promhttp.HandlerFor
+//
+//	r.GET("/metrics", gin.WrapH(middleware.Handler()))
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// registerCollector 註冊指標，已存在相同指標時回傳既有的指標
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, collector T) T {
+	if err := registerer.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return collector
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fixedValidator 回傳固定進行中驗證數的 InFlightValidator
+type fixedValidator int64
+
+func (v fixedValidator) InFlightValidations() int64 {
+	return int64(v)
+}
+
+func TestMetricsValidationsInFlight(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	router := gin.New()
+	router.Use(MetricsWithConfig(MetricsConfig{Registerer: registry, Validator: fixedValidator(3)}))
+	router.GET("/resource", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+	count, err := testutil.GatherAndCount(registry, "auth_validations_in_flight")
+	if err != nil || count != 1 {
+		t.Fatalf("auth_validations_in_flight series = %d, %v; want 1", count, err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "auth_validations_in_flight" {
+			if got := family.GetMetric()[0].GetGauge().GetValue(); got != 3 {
+				t.Errorf("auth_validations_in_flight = %v, want 3", got)
+			}
+		}
+	}
+}
+
+func TestMetricsWithoutValidator(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	MetricsWithConfig(MetricsConfig{Registerer: registry})
+
+	if count, err := testutil.GatherAndCount(registry, "auth_validations_in_flight"); err != nil || count != 0 {
+		t.Fatalf("auth_validations_in_flight series = %d, %v; want 0", count, err)
+	}
+}
+
+// gaugeValues 依 backend 標籤取出 rate_limiter_active_backend 的值
+func gaugeValues(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "rate_limiter_active_backend" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "backend" {
+					values[label.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
+
+func TestMetricsRateLimiterActiveBackend(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	limiter := NewRateLimiter(client, RateLimiterConfig{RetryInterval: time.Minute})
+	registry := prometheus.NewRegistry()
+	MetricsWithConfig(MetricsConfig{Registerer: registry, RateLimiter: limiter})
+
+	limiter.Allow(context.Background(), "k", 10, time.Minute)
+	if got := gaugeValues(t, registry); got[RateLimitBackendRedis] != 1 || got[RateLimitBackendMemory] != 0 {
+		t.Errorf("with redis up = %v, want redis active", got)
+	}
+
+	server.Close()
+	limiter.Allow(context.Background(), "k", 10, time.Minute)
+	if got := gaugeValues(t, registry); got[RateLimitBackendRedis] != 0 || got[RateLimitBackendMemory] != 1 {
+		t.Errorf("with redis down = %v, want memory active", got)
+	}
+}
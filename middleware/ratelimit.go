@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 限流器後端
+const (
+	RateLimitBackendRedis  = "redis"
+	RateLimitBackendMemory = "memory"
+)
+
+// RateLimiterConfig 限流器設定
+type RateLimiterConfig struct {
+	// KeyPrefix Redis key 前綴，預設 ratelimit:
+	KeyPrefix string
+	// RetryInterval Redis 失敗後改用記憶體限流，經過此間隔才再次嘗試 Redis，預設 5 秒
+	RetryInterval time.Duration
+	// IPResolver RateLimit 對未驗證請求以用戶端 IP 計數時使用，通常傳入 authClient.IPResolver()（nil 表示使用 c.ClientIP()）
+	IPResolver *auth.ClientIPResolver
+	Logger     *zap.Logger
+}
+
+// RateLimitResult 單次限流判斷結果
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // 被拒絕時，距離窗口內最早一筆請求過期的時間
+	Backend    string        // 做出判斷的後端：redis 或 memory
+}
+
+// RateLimiterStats 限流器統計
+type RateLimiterStats struct {
+	ActiveBackend   string `json:"active_backend"`   // 目前使用中的後端
+	RedisDecisions  uint64 `json:"redis_decisions"`  // 由 Redis 判斷的次數
+	MemoryDecisions uint64 `json:"memory_decisions"` // 由記憶體判斷的次數
+	RedisFailures   uint64 `json:"redis_failures"`   // Redis 查詢失敗、改用記憶體的次數
+}
+
+// RateLimiter 以 Redis 滑動窗口實作的跨實例限流器
+// Redis 無法使用時改用單一實例的記憶體限流：此時各實例各自計數，
+// 整體上限會變成約「上限 × 實例數」，僅為近似值，但仍優於完全不限流
+type RateLimiter struct {
+	client redis.Cmdable
+	config RateLimiterConfig
+	logger *zap.Logger
+	memory *memoryRateLimiter
+
+	// redisDownUntil Redis 失敗後暫停嘗試的截止時間（UnixNano，0 表示使用 Redis）
+	redisDownUntil atomic.Int64
+
+	redisDecisions  atomic.Uint64
+	memoryDecisions atomic.Uint64
+	redisFailures   atomic.Uint64
+}
+
+// NewRateLimiter 建立限流器，client 為 nil 時僅使用記憶體限流
+func NewRateLimiter(client redis.Cmdable, config RateLimiterConfig) *RateLimiter {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "ratelimit:"
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	}
+
+	return &RateLimiter{
+		client: client,
+		config: config,
+		logger: config.Logger,
+		memory: newMemoryRateLimiter(),
+	}
+}
+
+// Allow 記錄一次請求並判斷 key 在 window 內是否超過 limit 次
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) RateLimitResult {
+	now := time.Now()
+
+	if l.client != nil && now.UnixNano() >= l.redisDownUntil.Load() {
+		result, err := l.allowRedis(ctx, key, limit, window, now)
+		if err == nil {
+			if l.redisDownUntil.Swap(0) != 0 {
+				l.logger.Info("Rate limiter switched back to redis")
+			}
+			l.redisDecisions.Add(1)
+			return result
+		}
+
+		l.redisFailures.Add(1)
+		if l.redisDownUntil.Swap(now.Add(l.config.RetryInterval).UnixNano()) == 0 {
+			l.logger.Warn("Rate limiter falling back to in-memory limits, fleet-wide limits are approximate",
+				zap.Duration("retry_interval", l.config.RetryInterval),
+				zap.Error(err))
+		}
+	}
+
+	l.memoryDecisions.Add(1)
+	return l.memory.allow(key, limit, window, now)
+}
+
+// RateLimit 依路由限流的中間件：已驗證的請求以 user_id 計數，否則以用戶端 IP 計數
+// 需掛在 Authenticate 之後才能依用戶計數；每個路由模板各自計算額度
+// 回應帶有 X-RateLimit-Limit 與 X-RateLimit-Remaining，超出時回應 429 並帶 Retry-After（秒）
+func (l *RateLimiter) RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := "ip:" + l.config.IPResolver.ClientIP(c)
+		if userID, ok := auth.GetUserID(c); ok && userID != "" {
+			identity = "user:" + userID
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		result := l.Allow(c.Request.Context(), c.Request.Method+":"+route+":"+identity, limit, window)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			response.Error(c, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Rate limit exceeded, please retry later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ActiveBackend 回傳目前使用中的後端
+func (l *RateLimiter) ActiveBackend() string {
+	if l.client == nil || l.redisDownUntil.Load() != 0 {
+		return RateLimitBackendMemory
+	}
+	return RateLimitBackendRedis
+}
+
+// Stats 回傳限流器統計，可供監控判斷是否處於記憶體降級模式
+func (l *RateLimiter) Stats() RateLimiterStats {
+	return RateLimiterStats{
+		ActiveBackend:   l.ActiveBackend(),
+		RedisDecisions:  l.redisDecisions.Load(),
+		MemoryDecisions: l.memoryDecisions.Load(),
+		RedisFailures:   l.redisFailures.Load(),
+	}
+}
+
+// rateLimitScript 以 sorted set 實作滑動窗口：移除窗口外的紀錄後計數，未超過上限才加入本次請求
+// 回傳 {是否允許, 剩餘次數, 需等待的微秒數}
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// allowRedis 以 Redis 判斷
+func (l *RateLimiter) allowRedis(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (RateLimitResult, error) {
+	nowMicro := now.UnixMicro()
+	member := strconv.FormatInt(nowMicro, 10) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+
+	values, err := rateLimitScript.Run(ctx, l.client, []string{l.config.KeyPrefix + key},
+		nowMicro, window.Microseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Backend:    RateLimitBackendRedis,
+	}, nil
+}
+
+// memoryRateLimiter 單一實例的記憶體滑動窗口限流
+type memoryRateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+// memoryWindow 單一 key 窗口內的請求時間
+type memoryWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+// memorySweepInterval 清除閒置 key 的間隔
+const memorySweepInterval = time.Minute
+
+func newMemoryRateLimiter() *memoryRateLimiter {
+	return &memoryRateLimiter{
+		windows:   make(map[string]*memoryWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow 記憶體版本的滑動窗口判斷
+func (m *memoryRateLimiter) allow(key string, limit int, window time.Duration, now time.Time) RateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		m.sweep(now)
+	}
+
+	w, ok := m.windows[key]
+	if !ok {
+		w = &memoryWindow{}
+		m.windows[key] = w
+	}
+	w.window = window
+	w.prune(now)
+
+	result := RateLimitResult{Backend: RateLimitBackendMemory}
+	if len(w.hits) < limit {
+		w.hits = append(w.hits, now)
+		result.Allowed = true
+		result.Remaining = limit - len(w.hits)
+		return result
+	}
+	if len(w.hits) > 0 {
+		result.RetryAfter = w.hits[0].Add(window).Sub(now)
+	}
+	return result
+}
+
+// prune 移除窗口外的紀錄
+func (w *memoryWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(cutoff) {
+		i++
+	}
+	w.hits = w.hits[i:]
+}
+
+// sweep 清除窗口內已無紀錄的 key，避免記憶體無限成長
+func (m *memoryRateLimiter) sweep(now time.Time) {
+	for key, w := range m.windows {
+		w.prune(now)
+		if len(w.hits) == 0 {
+			delete(m.windows, key)
+		}
+	}
+	m.lastSweep = now
+}
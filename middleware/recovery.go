@@ -1,37 +1,101 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"runtime/debug"
+	"strings"
+	"syscall"
 
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// RecoveryConfig 恢復中間件設定
+type RecoveryConfig struct {
+	// OnPanic 記錄 panic 後、回應 500 前同步呼叫，用於回報 Sentry、累加告警指標等
+	// stack 為發生 panic 的 goroutine 堆疊；hook 本身的 panic 會被攔截並記錄
+	OnPanic func(c *gin.Context, recovered interface{}, stack []byte)
+}
+
 // Recovery 統一的恢復中間件
 // 提供統一的 panic 處理和結構化日誌記錄
 func Recovery(logger *zap.Logger) gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		// 記錄 panic 信息
-		logger.Error("Panic recovered",
-			zap.Any("error", recovered),
-			zap.String("method", c.Request.Method),
-			zap.String("path", c.Request.URL.Path),
-			zap.String("client_ip", c.ClientIP()),
-			zap.String("user_agent", c.Request.UserAgent()),
-			zap.String("stack", string(debug.Stack())),
-		)
-
-		// Add request ID if available
-		if requestID := c.GetString("request_id"); requestID != "" {
-			logger.Error("Panic context", zap.String("request_id", requestID))
-		}
+	return RecoveryWithConfig(logger, RecoveryConfig{})
+}
+
+// RecoveryWithConfig 依設定建立恢復中間件
+// 用戶端中途斷線造成的寫入錯誤（broken pipe、connection reset）只記錄警告、不附堆疊，
+// 也不呼叫 OnPanic 或寫入回應，避免對已關閉的連線回應 500 並干擾錯誤告警
+func RecoveryWithConfig(logger *zap.Logger, config RecoveryConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			if err, ok := recovered.(error); ok && isBrokenConnection(err) {
+				logger.Warn("Client connection closed",
+					zap.Error(err),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("client_ip", c.ClientIP()),
+				)
+				_ = c.Error(err)
+				c.Abort()
+				return
+			}
+
+			handlePanic(logger, config, c, recovered)
+		}()
+		c.Next()
+	}
+}
+
+// handlePanic 記錄 panic、呼叫 OnPanic 並回應 500
+func handlePanic(logger *zap.Logger, config RecoveryConfig, c *gin.Context, recovered interface{}) {
+	stack := debug.Stack()
 
-		// 返回統一错誤響應
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":     "Internal server error",
-			"message":   "An unexpected error occurred",
-			"timestamp": gin.H{},
-		})
-	})
+	// 記錄 panic 信息
+	logger.Error("Panic recovered",
+		zap.Any("error", recovered),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("client_ip", c.ClientIP()),
+		zap.String("user_agent", c.Request.UserAgent()),
+		zap.String("stack", string(stack)),
+	)
+
+	// Add request ID if available
+	if requestID := c.GetString("request_id"); requestID != "" {
+		logger.Error("Panic context", zap.String("request_id", requestID))
+	}
+
+	if config.OnPanic != nil {
+		runPanicHook(logger, config.OnPanic, c, recovered, stack)
+	}
+
+	// 返回統一错誤響應（與其他 handler 相同的 APIResponse 格式）
+	response.InternalServerError(c, "An unexpected error occurred")
+	c.Abort()
+}
+
+// isBrokenConnection 判斷錯誤是否為用戶端斷線（broken pipe 或 connection reset），含被包裝的錯誤
+func isBrokenConnection(err error) bool {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
+
+// runPanicHook 執行 OnPanic 並攔截 hook 本身的 panic，確保仍會回應 500
+func runPanicHook(logger *zap.Logger, hook func(*gin.Context, interface{}, []byte), c *gin.Context, recovered interface{}, stack []byte) {
+	defer func() {
+		if hookPanic := recover(); hookPanic != nil {
+			logger.Error("OnPanic hook panicked", zap.Any("error", hookPanic))
+		}
+	}()
+	hook(c, recovered, stack)
 }
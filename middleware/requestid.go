@@ -3,32 +3,114 @@ package middleware
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	auth "github.com/Spencer810704/devops-portal-auth-sdk"
 	"github.com/gin-gonic/gin"
 )
 
+// RequestIDConfig 請求ID中間件設定
+type RequestIDConfig struct {
+	// LegacyTimestampFormat 沿用舊格式「時間戳-8 位十六進位」（如 20240101120000-1a2b3c4d），供解析舊格式的系統使用
+	// 舊格式僅有 4 bytes 隨機值，同一秒內大量請求有碰撞可能；預設使用 UUIDv4
+	LegacyTimestampFormat bool
+
+	// MaxLength 接受用戶端傳入請求ID的最大長度，超過時重新生成，預設 128
+	MaxLength int
+	// Validator 判斷用戶端傳入的請求ID是否可用，不通過時重新生成；預設僅接受可見 ASCII 字元（不含空白與控制字元），
+	// 避免換行等字元造成日誌注入。長度檢查一律先於 Validator 執行
+	Validator func(requestID string) bool
+}
+
+// defaultRequestIDMaxLength 請求ID的預設最大長度
+const defaultRequestIDMaxLength = 128
+
 // RequestID 統一的請求ID中間件
-// 為每個請求生成唯一標識符，方便追蹤和除錯
+// 為每個請求生成唯一標識符（UUIDv4），方便追蹤和除錯
 func RequestID() gin.HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig 依設定建立請求ID中間件
+func RequestIDWithConfig(config RequestIDConfig) gin.HandlerFunc {
+	generate := generateRequestID
+	if config.LegacyTimestampFormat {
+		generate = generateTimestampRequestID
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = defaultRequestIDMaxLength
+	}
+	if config.Validator == nil {
+		config.Validator = isPrintableRequestID
+	}
+
 	return func(c *gin.Context) {
+		// 保留格式正確的用戶端請求ID，讓分散式追蹤得以串接；其餘重新生成
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = generateRequestID()
+		if requestID == "" || len(requestID) > config.MaxLength || !config.Validator(requestID) {
+			requestID = generate()
 		}
 		
+		// 覆寫請求標頭，讓直接讀取標頭的日誌等程式碼也只會取得檢查過的值
+		c.Request.Header.Set("X-Request-ID", requestID)
 		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+		c.Set(auth.ContextKeyRequestID, requestID)
+		// 一併放入 request context，讓 auth.Client 等以 context 呼叫的程式碼能取得
+		c.Request = c.Request.WithContext(auth.ContextWithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
 
-// generateRequestID 生成唯一請求ID
-// 使用時間戳 + 隨機字符串的方式生成
+// isPrintableRequestID 是否僅包含可見 ASCII 字元
+func isPrintableRequestID(requestID string) bool {
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRequestID 生成 UUIDv4 格式的請求ID
 func generateRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fallbackRequestID()
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// generateTimestampRequestID 生成舊格式的請求ID
+// 使用時間戳 + 隨機字符串的方式生成
+func generateTimestampRequestID() string {
 	timestamp := time.Now().Format("20060102150405")
 	randomBytes := make([]byte, 4)
-	rand.Read(randomBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return fallbackRequestID()
+	}
 	randomHex := hex.EncodeToString(randomBytes)
 	return timestamp + "-" + randomHex
 }
+
+// requestIDCounter 隨機來源失敗時的遞增序號
+var requestIDCounter atomic.Uint64
+
+// fallbackRequestID 系統隨機來源無法使用時，以奈秒時間戳加遞增序號確保單一實例內不重複
+func fallbackRequestID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(requestIDCounter.Add(1), 36)
+}
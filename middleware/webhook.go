@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Spencer810704/devops-portal-auth-sdk/response"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SignatureHeader 請求簽章標頭，格式為 sha256=<hex>
+	SignatureHeader = "X-Signature"
+	// SignatureKeyIDHeader 簽章金鑰識別標頭，傳給 secretProvider 以支援多組金鑰
+	SignatureKeyIDHeader = "X-Signature-Key-ID"
+
+	// maxWebhookBodySize Webhook 請求內容大小上限（10MB）
+	maxWebhookBodySize = 10 << 20
+)
+
+// WebhookSignature Webhook 簽章驗證中間件
+// 以 HMAC-SHA256 計算原始請求內容並以常數時間比對 X-Signature，
+// 讀取後會還原 Body 讓後續 handler 可再次讀取
+func WebhookSignature(secretProvider func(id string) ([]byte, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signature, ok := strings.CutPrefix(c.GetHeader(SignatureHeader), "sha256=")
+		if !ok || signature == "" {
+			response.Unauthorized(c, "Missing or invalid webhook signature")
+			c.Abort()
+			return
+		}
+
+		expected, err := hex.DecodeString(signature)
+		if err != nil {
+			response.Unauthorized(c, "Missing or invalid webhook signature")
+			c.Abort()
+			return
+		}
+
+		secret, err := secretProvider(c.GetHeader(SignatureKeyIDHeader))
+		if err != nil || len(secret) == 0 {
+			response.Unauthorized(c, "Unknown webhook signing key")
+			c.Abort()
+			return
+		}
+
+		// 讀取原始內容並還原，避免後續 handler 讀到空 Body
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+		if err != nil {
+			response.BadRequest(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if !hmac.Equal(mac.Sum(nil), expected) {
+			response.Unauthorized(c, "Webhook signature mismatch")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ConsumeOnceToken 驗證一次性 token（如 magic link、信箱驗證）並透過權限儲存將其 jti 標記為已使用，
+// 同一 token 再次使用時回傳 ErrTokenAlreadyUsed
+// 標記保留至 token 過期為止，過期後 token 本身已無法通過驗證；token 須帶有 jti 與 exp，否則回傳 ErrMissingTokenID
+// 不檢查 token_type，也不查詢用戶狀態；權限儲存須實作 OnceTokenStore（否則回傳 ErrOnceTokenUnsupported），
+// 儲存無法使用時回傳錯誤，不會放行
+func (c *Client) ConsumeOnceToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := c.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, ErrMissingTokenID
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time) + c.config.ClockSkew
+	if ttl <= 0 {
+		return nil, ErrTokenExpired
+	}
+
+	onceStore, ok := c.store.(OnceTokenStore)
+	if !ok {
+		return nil, ErrOnceTokenUnsupported
+	}
+	consumed, err := onceStore.MarkTokenConsumed(ctx, claims.ID, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark token as consumed: %w", err)
+	}
+	if !consumed {
+		return nil, fmt.Errorf("%w: jti %s", ErrTokenAlreadyUsed, claims.ID)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// onceClaims 回傳帶有 jti 的一次性 token 聲明
+func onceClaims(tokenID string) *Claims {
+	claims := testClaims("42")
+	claims.ID = tokenID
+	return claims
+}
+
+func TestConsumeOnceTokenSecondUseFails(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: path, RedisClient: redisClient, RedisKeyPrefix: "portal:"})
+	token := signTestToken(t, jwt.SigningMethodRS256, key, onceClaims("magic-1"), nil)
+
+	claims, err := client.ConsumeOnceToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("first ConsumeOnceToken: %v", err)
+	}
+	if claims.ID != "magic-1" {
+		t.Errorf("claims.ID = %q, want magic-1", claims.ID)
+	}
+	if !server.Exists("portal:token:consumed:magic-1") {
+		t.Errorf("consumed marker not written, keys %v", server.Keys())
+	}
+	if ttl := server.TTL("portal:token:consumed:magic-1"); ttl <= 0 || ttl > time.Hour+time.Minute {
+		t.Errorf("consumed marker TTL = %v, want until token expiry", ttl)
+	}
+
+	if _, err := client.ConsumeOnceToken(context.Background(), token); !errors.Is(err, ErrTokenAlreadyUsed) {
+		t.Fatalf("second ConsumeOnceToken err = %v, want ErrTokenAlreadyUsed", err)
+	}
+
+	other := signTestToken(t, jwt.SigningMethodRS256, key, onceClaims("magic-2"), nil)
+	if _, err := client.ConsumeOnceToken(context.Background(), other); err != nil {
+		t.Errorf("ConsumeOnceToken with another jti: %v", err)
+	}
+}
+
+func TestConsumeOnceTokenRequiresTokenID(t *testing.T) {
+	key, path := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: path})
+	token := signTestToken(t, jwt.SigningMethodRS256, key, onceClaims(""), nil)
+
+	if _, err := client.ConsumeOnceToken(context.Background(), token); !errors.Is(err, ErrMissingTokenID) {
+		t.Errorf("ConsumeOnceToken err = %v, want ErrMissingTokenID", err)
+	}
+}
+
+func TestConsumeOnceTokenThroughStore(t *testing.T) {
+	_, redisClient := newTestRedis(t)
+	redisStore := NewRedisStore(redisClient, RedisStoreConfig{})
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr error
+	}{
+		{name: "custom store", config: Config{Store: redisStore}},
+		{
+			name:   "fallback chain uses first tier",
+			config: Config{Store: redisStore, FallbackStores: []StoreTier{{Name: "secondary", Store: NewRedisStore(redisClient, RedisStoreConfig{})}}},
+		},
+		{name: "store without capability", config: Config{Store: singlePermissionStore{redisStore}}, wantErr: ErrOnceTokenUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, path := newRSAKey(t)
+			config := tt.config
+			config.PublicKeyPath = path
+			client := newTestClient(t, &config)
+			token := signTestToken(t, jwt.SigningMethodRS256, key, onceClaims(tt.name), nil)
+
+			if _, err := client.ConsumeOnceToken(context.Background(), token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("first ConsumeOnceToken err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err := client.ConsumeOnceToken(context.Background(), token); !errors.Is(err, ErrTokenAlreadyUsed) {
+				t.Errorf("second ConsumeOnceToken err = %v, want ErrTokenAlreadyUsed", err)
+			}
+		})
+	}
+}
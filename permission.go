@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Permission 經過格式驗證的權限值，格式為以冒號分隔的區段（如 cdn:zones:write）
+// 區段僅能包含英數字、底線、連字號與點，或整段為萬用字元 *（匹配一個區段）；單獨的 * 代表所有權限
+// 最後一個區段可為 **，匹配其後一個以上的區段，如 orders:** 涵蓋 orders:read 與 orders:read:own；不接受單獨的 **
+type Permission string
+
+// ParsePermission 解析並驗證權限字串
+func ParsePermission(s string) (Permission, error) {
+	if s == "*" {
+		return Permission(s), nil
+	}
+
+	segments := strings.Split(s, ":")
+	if len(segments) < 2 {
+		return "", fmt.Errorf("invalid permission %q: expected at least two segments separated by ':'", s)
+	}
+	for i, segment := range segments {
+		if segment == "*" {
+			continue
+		}
+		if segment == "**" {
+			if i != len(segments)-1 {
+				return "", fmt.Errorf("invalid permission %q: ** is only allowed as the last segment", s)
+			}
+			continue
+		}
+		if segment == "" {
+			return "", fmt.Errorf("invalid permission %q: segment %d is empty", s, i+1)
+		}
+		if strings.Contains(segment, "*") {
+			return "", fmt.Errorf("invalid permission %q: wildcard must be a whole segment", s)
+		}
+		for _, r := range segment {
+			if !isPermissionRune(r) {
+				return "", fmt.Errorf("invalid permission %q: unexpected character %q", s, r)
+			}
+		}
+	}
+
+	return Permission(s), nil
+}
+
+// MustParsePermission 同 ParsePermission，格式錯誤時 panic，適用於套件層級的權限常數
+func MustParsePermission(s string) Permission {
+	p, err := ParsePermission(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Matches 判斷此權限（可含萬用字元）是否涵蓋 required，規則與中介軟體的權限檢查相同
+func (p Permission) Matches(required Permission) bool {
+	return hasPermission([]string{string(p)}, string(required))
+}
+
+// String 回傳權限字串，供字串型 API 使用
+func (p Permission) String() string {
+	return string(p)
+}
+
+// isPermissionRune 判斷字元是否可用於權限區段
+func isPermissionRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '-' || r == '.'
+}
+
+// DiffPermissions 比較新舊權限集合，回傳新增與移除的權限（供稽核日誌使用）
+// 萬用字元權限視為一般字串，不做展開；結果保留輸入順序並去除重複
+func DiffPermissions(oldPermissions, newPermissions []string) (added, removed []string) {
+	oldSet := make(map[string]struct{}, len(oldPermissions))
+	for _, perm := range oldPermissions {
+		oldSet[perm] = struct{}{}
+	}
+	newSet := make(map[string]struct{}, len(newPermissions))
+	for _, perm := range newPermissions {
+		newSet[perm] = struct{}{}
+	}
+
+	for _, perm := range newPermissions {
+		if _, ok := oldSet[perm]; !ok {
+			added = append(added, perm)
+			oldSet[perm] = struct{}{} // 避免重複輸出
+		}
+	}
+	for _, perm := range oldPermissions {
+		if _, ok := newSet[perm]; !ok {
+			removed = append(removed, perm)
+			newSet[perm] = struct{}{} // 避免重複輸出
+		}
+	}
+
+	return added, removed
+}
+
+// permissionNearMiss 未滿足的必要權限與最接近的用戶權限，僅供拒絕日誌診斷
+type permissionNearMiss struct {
+	Required        string `json:"required"`
+	Closest         string `json:"closest,omitempty"` // 自開頭起相符區段最多的用戶權限
+	MatchedSegments int    `json:"matched_segments"`  // Closest 自開頭起相符的區段數（* 視為相符）
+	Reason          string `json:"reason,omitempty"`  // Closest 未能涵蓋 Required 的原因
+}
+
+// nearMisses 對每個必要權限找出最接近的用戶權限，說明看似正確的授權為何沒有匹配
+// 相符區段數相同時取較先出現者；沒有任何區段相符時 Closest 為空
+func nearMisses(userPermissions, required []string) []permissionNearMiss {
+	misses := make([]permissionNearMiss, 0, len(required))
+	for _, requiredPerm := range required {
+		miss := permissionNearMiss{Required: requiredPerm}
+		for _, perm := range userPermissions {
+			matched, reason := comparePermissionSegments(perm, requiredPerm)
+			if matched > miss.MatchedSegments {
+				miss.Closest = perm
+				miss.MatchedSegments = matched
+				miss.Reason = reason
+			}
+		}
+		misses = append(misses, miss)
+	}
+	return misses
+}
+
+// comparePermissionSegments 逐段比較用戶權限與必要權限，回傳自開頭起相符的區段數與第一個差異
+func comparePermissionSegments(userPerm, requiredPerm string) (int, string) {
+	userParts := strings.Split(userPerm, ":")
+	requiredParts := strings.Split(requiredPerm, ":")
+
+	// 結尾的 ** 需要其後至少還有一個區段；單獨的 ** 不匹配任何權限
+	trailing := false
+	if last := len(userParts) - 1; userParts[last] == "**" {
+		if last == 0 {
+			return 0, "bare ** matches nothing, use * instead"
+		}
+		trailing = true
+		userParts = userParts[:last]
+	}
+
+	matched := 0
+	for i, userPart := range userParts {
+		if i >= len(requiredParts) {
+			break
+		}
+		if userPart != "*" && userPart != requiredParts[i] {
+			return matched, fmt.Sprintf("segment %d: have %q, need %q", i+1, userPart, requiredParts[i])
+		}
+		matched++
+	}
+
+	switch {
+	case trailing && len(requiredParts) <= len(userParts):
+		return matched, fmt.Sprintf("segment count: ** needs at least %d, need %d", len(userParts)+1, len(requiredParts))
+	case !trailing && len(userParts) != len(requiredParts):
+		return matched, fmt.Sprintf("segment count: have %d, need %d", len(userParts), len(requiredParts))
+	}
+	return matched, ""
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// parsePermissionsCache 解析動態權限快取內容
+// 支援以下格式（依序嘗試）：
+//   - 包裝物件：{"permissions": ["a", "b"]}（permissions 亦可為逗號分隔字串）
+//   - 純陣列：["a", "b"]
+//   - 逗號或空白分隔字串：a,b c（可為 JSON 字串或未加引號的原始值）
+//
+// 未加引號的原始值須每一項都通過 ParsePermission 驗證，否則回傳包裝 ErrCorruptCache 的錯誤，
+// 避免 null、true、123 或其他寫壞的內容被當成權限名稱
+//
+// 陣列項目亦可為帶期限的授權 {"permission": "a", "expires_at": "2024-01-01T00:00:00Z"}，
+// 讀取時即過濾已過期的項目
+func parsePermissionsCache(val string) ([]string, error) {
+	return parsePermissionsCacheAt(val, time.Now())
+}
+
+// parsePermissionsCacheAt 以 now 作為判斷授權是否過期的時間點解析快取內容
+func parsePermissionsCacheAt(val string, now time.Time) ([]string, error) {
+	trimmed := strings.TrimSpace(val)
+
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		var cacheData map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &cacheData); err != nil {
+			return nil, err
+		}
+		permissions, ok := cacheData["permissions"]
+		if !ok {
+			return nil, errors.New("missing permissions field")
+		}
+		return parsePermissionsValue(permissions, now)
+
+	case strings.HasPrefix(trimmed, "["), strings.HasPrefix(trimmed, `"`):
+		var data interface{}
+		if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+			return nil, err
+		}
+		return parsePermissionsValue(data, now)
+
+	default:
+		return parsePermissionList(trimmed)
+	}
+}
+
+// parsePermissionList 切分未加引號的權限字串，任一項目不是合法權限時回傳包裝 ErrCorruptCache 的錯誤
+func parsePermissionList(s string) ([]string, error) {
+	permissions := splitPermissionList(s)
+	for _, perm := range permissions {
+		if _, err := ParsePermission(perm); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCorruptCache, err)
+		}
+	}
+	return permissions, nil
+}
+
+// parsePermissionsValue 將 JSON 值轉換為權限列表，略過在 now 之前到期的授權
+func parsePermissionsValue(value interface{}, now time.Time) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		permissions := make([]string, 0, len(v))
+		for _, perm := range v {
+			switch entry := perm.(type) {
+			case string:
+				if entry != "" {
+					permissions = append(permissions, entry)
+				}
+			case map[string]interface{}:
+				if permStr, ok := activeGrant(entry, now); ok {
+					permissions = append(permissions, permStr)
+				}
+			}
+		}
+		return permissions, nil
+	case string:
+		return splitPermissionList(v), nil
+	default:
+		return nil, fmt.Errorf("invalid permissions type %T", value)
+	}
+}
+
+// activeGrant 解析帶期限的授權項目，回傳尚未到期的權限
+// 未設定 expires_at 視為永久；expires_at 無法解析時視為已到期，避免臨時授權意外變成永久
+func activeGrant(entry map[string]interface{}, now time.Time) (string, bool) {
+	permission, ok := entry["permission"].(string)
+	if !ok || permission == "" {
+		return "", false
+	}
+
+	raw, ok := entry["expires_at"]
+	if !ok || raw == nil {
+		return permission, true
+	}
+
+	expiresAt, err := parseCacheTime("expires_at", raw)
+	if err != nil || !now.Before(expiresAt) {
+		return "", false
+	}
+	return permission, true
+}
+
+// splitPermissionList 以逗號或空白切分權限字串，忽略空項目
+func splitPermissionList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// canonicalPermissionsCache 將非標準格式的快取內容轉為標準的包裝物件，內容已是標準格式或無法解析時回傳 false
+// 陣列項目原樣保留（含帶期限的授權），包裝物件的其他欄位（如 updated_at）一併保留；
+// 原內容沒有寫入時間時不補上，避免 GetPermissionCacheAge 誤判快取為剛寫入；
+// 任何格式中的每一項（含授權物件的 permission）皆須為合法權限（見 ParsePermission），否則不改寫
+func canonicalPermissionsCache(val string) (string, bool) {
+	trimmed := strings.TrimSpace(val)
+
+	var cacheData map[string]interface{}
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		if err := json.Unmarshal([]byte(trimmed), &cacheData); err != nil {
+			return "", false
+		}
+		list, ok := cacheData["permissions"].(string)
+		if !ok {
+			return "", false
+		}
+		permissions, ok := permissionListValue(list)
+		if !ok {
+			return "", false
+		}
+		cacheData["permissions"] = permissions
+
+	case strings.HasPrefix(trimmed, "["):
+		var entries []interface{}
+		if err := json.Unmarshal([]byte(trimmed), &entries); err != nil || !validPermissionEntries(entries) {
+			return "", false
+		}
+		cacheData = map[string]interface{}{"permissions": entries}
+
+	case strings.HasPrefix(trimmed, `"`):
+		var list string
+		if err := json.Unmarshal([]byte(trimmed), &list); err != nil {
+			return "", false
+		}
+		permissions, ok := permissionListValue(list)
+		if !ok {
+			return "", false
+		}
+		cacheData = map[string]interface{}{"permissions": permissions}
+
+	default:
+		permissions, ok := permissionListValue(trimmed)
+		if !ok {
+			return "", false
+		}
+		cacheData = map[string]interface{}{"permissions": permissions}
+	}
+
+	encoded, err := json.Marshal(cacheData)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// permissionListValue 切分並驗證權限字串，空字串轉為空陣列而非 null；任一項目不合法時回傳 false
+func permissionListValue(s string) ([]string, bool) {
+	permissions, err := parsePermissionList(s)
+	if err != nil {
+		return nil, false
+	}
+	return append([]string{}, permissions...), true
+}
+
+// validPermissionEntries 檢查陣列中每一項皆為合法權限字串，或 permission 為合法權限的授權物件
+func validPermissionEntries(entries []interface{}) bool {
+	for _, entry := range entries {
+		if grant, ok := entry.(map[string]interface{}); ok {
+			entry = grant["permission"]
+		}
+		perm, ok := entry.(string)
+		if !ok {
+			return false
+		}
+		if _, err := ParsePermission(perm); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePermissionsCacheTimestamp 取得包裝物件中的寫入時間
+// 優先讀取 updated_at，兼容舊版寫入端使用的 cached_at；
+// 值可為 RFC3339 字串或 Unix 秒數
+func parsePermissionsCacheTimestamp(val string) (time.Time, error) {
+	var cacheData map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(val)), &cacheData); err != nil {
+		return time.Time{}, ErrCacheTimestampMissing // 非包裝物件格式不帶時間戳
+	}
+
+	for _, field := range []string{"updated_at", "cached_at"} {
+		raw, ok := cacheData[field]
+		if !ok || raw == nil {
+			continue
+		}
+
+		return parseCacheTime(field, raw)
+	}
+
+	return time.Time{}, ErrCacheTimestampMissing
+}
+
+// parseCacheTime 解析快取中的時間欄位，值可為 RFC3339 字串或 Unix 秒數
+func parseCacheTime(field string, raw interface{}) (time.Time, error) {
+	switch v := raw.(type) {
+	case string:
+		if ts, err := time.Parse(time.RFC3339, v); err == nil {
+			return ts, nil
+		}
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0), nil
+		}
+		return time.Time{}, fmt.Errorf("invalid %s value %q", field, v)
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid %s type %T", field, raw)
+	}
+}
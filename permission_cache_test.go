@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParsePermissionsCache(t *testing.T) {
+	tests := []struct {
+		name string
+		val  string
+		want []string
+	}{
+		{name: "wrapped array", val: `{"permissions": ["user:read", "user:write"]}`, want: []string{"user:read", "user:write"}},
+		{name: "wrapped csv", val: `{"permissions": "user:read, user:write"}`, want: []string{"user:read", "user:write"}},
+		{name: "wrapped with timestamp", val: `{"permissions": ["user:read"], "updated_at": "2024-01-01T00:00:00Z"}`, want: []string{"user:read"}},
+		{name: "bare array", val: `["user:read", "user:write"]`, want: []string{"user:read", "user:write"}},
+		{name: "bare array skips empty items", val: `["user:read", ""]`, want: []string{"user:read"}},
+		{name: "json string csv", val: `"user:read,user:write"`, want: []string{"user:read", "user:write"}},
+		{name: "unquoted csv", val: "user:read,user:write", want: []string{"user:read", "user:write"}},
+		{name: "unquoted whitespace separated", val: " user:read  user:write\n", want: []string{"user:read", "user:write"}},
+		{name: "unquoted wildcard", val: "*", want: []string{"*"}},
+		{name: "empty", val: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePermissionsCache(tt.val)
+			if err != nil {
+				t.Fatalf("parsePermissionsCache(%q) error = %v", tt.val, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parsePermissionsCache(%q) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePermissionsCacheRejectsJunk(t *testing.T) {
+	for _, val := range []string{"null", "true", "123", "<html>", "user:read,oops", "user::read"} {
+		t.Run(val, func(t *testing.T) {
+			got, err := parsePermissionsCache(val)
+			if !errors.Is(err, ErrCorruptCache) {
+				t.Fatalf("parsePermissionsCache(%q) = %q, %v; want ErrCorruptCache", val, got, err)
+			}
+		})
+	}
+}
+
+func TestRedisStoreJunkPermissionsCache(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.Set("user:dynamic_permissions:42", "null")
+
+	store := NewRedisStore(client, RedisStoreConfig{DeleteCorruptCache: true})
+	if _, err := store.GetDynamicPermissions(context.Background(), "42"); !errors.Is(err, ErrCorruptCache) {
+		t.Fatalf("GetDynamicPermissions err = %v, want ErrCorruptCache", err)
+	}
+	if server.Exists("user:dynamic_permissions:42") {
+		t.Error("corrupt permissions cache was not deleted")
+	}
+}
+
+func TestCanonicalPermissionsCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		val    string
+		want   string
+		wantOK bool
+	}{
+		{name: "wrapped csv", val: `{"permissions": "user:read, user:write"}`, want: `{"permissions":["user:read","user:write"]}`, wantOK: true},
+		{name: "wrapped csv keeps other fields", val: `{"permissions": "user:read", "updated_at": "2024-01-01T00:00:00Z"}`, want: `{"permissions":["user:read"],"updated_at":"2024-01-01T00:00:00Z"}`, wantOK: true},
+		{name: "bare array keeps grants", val: `["user:read", {"permission": "user:write", "expires_at": "2030-01-01T00:00:00Z"}]`, want: `{"permissions":["user:read",{"expires_at":"2030-01-01T00:00:00Z","permission":"user:write"}]}`, wantOK: true},
+		{name: "quoted csv", val: `"user:read,user:write"`, want: `{"permissions":["user:read","user:write"]}`, wantOK: true},
+		{name: "bare csv", val: "user:read user:write", want: `{"permissions":["user:read","user:write"]}`, wantOK: true},
+		{name: "bare empty", val: "  ", want: `{"permissions":[]}`, wantOK: true},
+		{name: "already canonical", val: `{"permissions": ["user:read"]}`},
+		{name: "bare junk item", val: "user:read,oops"},
+		{name: "wrapped csv junk item", val: `{"permissions": "user:read,oops"}`},
+		{name: "quoted csv junk item", val: `"user:read,oops"`},
+		{name: "array junk item", val: `["user:read", "oops"]`},
+		{name: "array non-string item", val: `["user:read", 123]`},
+		{name: "array junk grant", val: `["user:read", {"permission": "oops"}]`},
+		{name: "array grant without permission", val: `[{"expires_at": "2030-01-01T00:00:00Z"}]`},
+		{name: "bare empty segment", val: "user::read"},
+		{name: "bare null", val: "null"},
+		{name: "html", val: "<html>"},
+		{name: "invalid json", val: `{"permissions": `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := canonicalPermissionsCache(tt.val)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("canonicalPermissionsCache(%q) = %q, %v; want %q, %v", tt.val, got, ok, tt.want, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			// 改寫前後解析出的權限必須相同
+			before, err := parsePermissionsCache(tt.val)
+			if err != nil {
+				t.Fatalf("parse original: %v", err)
+			}
+			after, err := parsePermissionsCache(got)
+			if err != nil {
+				t.Fatalf("parse canonical: %v", err)
+			}
+			if !slices.Equal(before, after) {
+				t.Errorf("permissions changed by rewrite: %q → %q", before, after)
+			}
+			if _, ok := canonicalPermissionsCache(got); ok {
+				t.Errorf("canonical value %q would be rewritten again", got)
+			}
+		})
+	}
+}
+
+func TestRedisStoreRewritePermissionsOnRead(t *testing.T) {
+	const key = "user:dynamic_permissions:42"
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		val       string
+		want      string
+		wantPerms []string
+		wantErr   error
+	}{
+		{name: "rewrites csv", enabled: true, val: "user:read,user:write", want: `{"permissions":["user:read","user:write"]}`, wantPerms: []string{"user:read", "user:write"}},
+		{name: "rewrites bare array", enabled: true, val: `["user:read"]`, want: `{"permissions":["user:read"]}`, wantPerms: []string{"user:read"}},
+		{name: "keeps canonical value", enabled: true, val: `{"permissions":["user:read"]}`, want: `{"permissions":["user:read"]}`, wantPerms: []string{"user:read"}},
+		{name: "leaves junk untouched", enabled: true, val: "user:read,oops", want: "user:read,oops", wantErr: ErrCorruptCache},
+		{name: "leaves quoted junk untouched", enabled: true, val: `"user:read,oops"`, want: `"user:read,oops"`, wantPerms: []string{"user:read", "oops"}},
+		{name: "leaves array junk untouched", enabled: true, val: `["user:read", {"permission": "oops"}]`, want: `["user:read", {"permission": "oops"}]`, wantPerms: []string{"user:read", "oops"}},
+		{name: "disabled", val: "user:read,user:write", want: "user:read,user:write", wantPerms: []string{"user:read", "user:write"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newTestRedis(t)
+			server.Set(key, tt.val)
+			server.SetTTL(key, 10*time.Minute)
+
+			store := NewRedisStore(client, RedisStoreConfig{RewritePermissionsOnRead: tt.enabled})
+			perms, err := store.GetDynamicPermissions(context.Background(), "42")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetDynamicPermissions err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(perms, tt.wantPerms) {
+				t.Errorf("GetDynamicPermissions = %q, want %q", perms, tt.wantPerms)
+			}
+
+			got, err := server.Get(key)
+			if err != nil {
+				t.Fatalf("read back: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("stored value = %q, want %q", got, tt.want)
+			}
+			if ttl := server.TTL(key); ttl != 10*time.Minute {
+				t.Errorf("TTL = %v, want the original 10m", ttl)
+			}
+
+			// 改寫後再次讀取得到相同權限
+			if tt.wantErr == nil {
+				again, err := store.GetDynamicPermissions(context.Background(), "42")
+				if err != nil || !slices.Equal(again, tt.wantPerms) {
+					t.Errorf("second read = %q, %v; want %q", again, err, tt.wantPerms)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisStoreRewritePermissionsWithoutTTL(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.Set("user:dynamic_permissions:42", "user:read")
+
+	store := NewRedisStore(client, RedisStoreConfig{RewritePermissionsOnRead: true})
+	if _, err := store.GetDynamicPermissions(context.Background(), "42"); err != nil {
+		t.Fatalf("GetDynamicPermissions: %v", err)
+	}
+	if got, _ := server.Get("user:dynamic_permissions:42"); got != `{"permissions":["user:read"]}` {
+		t.Errorf("stored value = %q", got)
+	}
+	if ttl := server.TTL("user:dynamic_permissions:42"); ttl != 0 {
+		t.Errorf("TTL = %v, want none", ttl)
+	}
+}
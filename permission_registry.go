@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"slices"
+	"sync"
+)
+
+// permissionRegistry 記錄授權中介軟體建立時引用的權限與角色，供安全審查列出服務的授權範圍
+type permissionRegistry struct {
+	mu          sync.Mutex
+	permissions map[string]struct{}
+	roles       map[string]struct{}
+}
+
+// newPermissionRegistry 建立空的登記表
+func newPermissionRegistry() *permissionRegistry {
+	return &permissionRegistry{
+		permissions: make(map[string]struct{}),
+		roles:       make(map[string]struct{}),
+	}
+}
+
+// addPermissions 登記權限，略過空字串
+func (r *permissionRegistry) addPermissions(permissions ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, permission := range permissions {
+		if permission != "" {
+			r.permissions[permission] = struct{}{}
+		}
+	}
+}
+
+// addRoles 登記角色，略過空字串
+func (r *permissionRegistry) addRoles(roles ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, role := range roles {
+		if role != "" {
+			r.roles[role] = struct{}{}
+		}
+	}
+}
+
+// addExpr 登記授權表達式中引用的權限與角色；無法展開的自訂 Expr 實作會被略過
+func (r *permissionRegistry) addExpr(expr Expr) {
+	switch e := expr.(type) {
+	case AuthzCheck:
+		r.addPermissions(e.Permission)
+		r.addRoles(e.Role)
+	case andExpr:
+		for _, sub := range e {
+			r.addExpr(sub)
+		}
+	case orExpr:
+		for _, sub := range e {
+			r.addExpr(sub)
+		}
+	case notExpr:
+		r.addExpr(e.expr)
+	}
+}
+
+// sortedKeys 回傳排序後的登記內容
+func (r *permissionRegistry) sortedKeys(set map[string]struct{}) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// RegisteredPermissions 回傳經由此中介軟體（含 WithDryRun 的副本）建立的授權檢查所引用的權限，已去除重複並排序
+// 涵蓋 RequirePermission、RequireAnyPermission、RequireAny、RequireExpr 與 RouteAuthz；
+// 以原始字串列出，萬用字元不展開。Not 條件中的權限也會列出，用於與 Auth 服務定義的權限交叉比對
+// 僅包含已建立的檢查，請於所有路由註冊完成後呼叫
+func (m *GinMiddleware) RegisteredPermissions() []string {
+	return m.registry.sortedKeys(m.registry.permissions)
+}
+
+// RegisteredRoles 回傳經由此中介軟體建立的角色檢查（RequireRole、RequireAnyRole、RequireAny、RequireExpr）所引用的角色，
+// 已去除重複並排序
+func (m *GinMiddleware) RegisteredRoles() []string {
+	return m.registry.sortedKeys(m.registry.roles)
+}
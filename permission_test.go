@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLimitPermissions(t *testing.T) {
+	permissions := []string{"a:read", "b:read", "c:read"}
+
+	tests := []struct {
+		name     string
+		limit    int
+		input    []string
+		want     []string
+		wantWarn bool
+	}{
+		{name: "unlimited", limit: 0, input: permissions, want: permissions},
+		{name: "negative is unlimited", limit: -1, input: permissions, want: permissions},
+		{name: "under limit", limit: 5, input: permissions, want: permissions},
+		{name: "at limit", limit: 3, input: permissions, want: permissions},
+		{name: "over limit", limit: 2, input: permissions, want: []string{"a:read", "b:read"}, wantWarn: true},
+		{name: "nil input", limit: 2, input: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+			c := &Client{config: &Config{MaxPermissions: tt.limit}, logger: zap.New(core)}
+
+			got := c.limitPermissions("42", "dynamic", tt.input)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("limitPermissions() = %q, want %q", got, tt.want)
+			}
+			if warned := logs.Len() > 0; warned != tt.wantWarn {
+				t.Errorf("warned = %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestMaxPermissionsAppliesToTokenAndDynamicPermissions(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	key, publicKeyPath := newRSAKey(t)
+	client := newTestClient(t, &Config{PublicKeyPath: publicKeyPath, RedisClient: redisClient, MaxPermissions: 2})
+
+	claims := testClaims("42")
+	claims.Permissions = []string{"a:read", "b:read", "c:read"}
+	token := signTestToken(t, jwt.SigningMethodRS256, key, claims, nil)
+
+	validated, err := client.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if want := []string{"a:read", "b:read"}; !slices.Equal(validated.Permissions, want) {
+		t.Errorf("token permissions = %q, want %q", validated.Permissions, want)
+	}
+
+	server.Set("user:dynamic_permissions:42", `["x:read", "y:read", "z:read"]`)
+	result, err := client.ValidateTokenWithDynamicAuth(context.Background(), token)
+	if err != nil {
+		t.Fatalf("ValidateTokenWithDynamicAuth: %v", err)
+	}
+	if want := []string{"x:read", "y:read"}; !slices.Equal(result.DynamicPermissions, want) {
+		t.Errorf("dynamic permissions = %q, want %q", result.DynamicPermissions, want)
+	}
+}
+
+func TestDiffPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		old, new    []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{name: "both empty"},
+		{name: "all added", new: []string{"a:read", "b:read"}, wantAdded: []string{"a:read", "b:read"}},
+		{name: "all removed", old: []string{"a:read", "b:read"}, wantRemoved: []string{"a:read", "b:read"}},
+		{name: "unchanged", old: []string{"a:read", "b:read"}, new: []string{"b:read", "a:read"}},
+		{
+			name:        "added and removed keep input order",
+			old:         []string{"c:read", "a:read", "b:read"},
+			new:         []string{"d:read", "b:read", "e:read"},
+			wantAdded:   []string{"d:read", "e:read"},
+			wantRemoved: []string{"c:read", "a:read"},
+		},
+		{
+			name:        "duplicates reported once",
+			old:         []string{"a:read", "a:read"},
+			new:         []string{"b:read", "b:read"},
+			wantAdded:   []string{"b:read"},
+			wantRemoved: []string{"a:read"},
+		},
+		{
+			name:        "wildcards compared as plain strings",
+			old:         []string{"orders:*"},
+			new:         []string{"orders:read"},
+			wantAdded:   []string{"orders:read"},
+			wantRemoved: []string{"orders:*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := DiffPermissions(tt.old, tt.new)
+			if !slices.Equal(added, tt.wantAdded) {
+				t.Errorf("added = %q, want %q", added, tt.wantAdded)
+			}
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("removed = %q, want %q", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestHasPermissionWildcards(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     string
+		required string
+		want     bool
+	}{
+		{name: "exact", perm: "orders:read", required: "orders:read", want: true},
+		{name: "star matches one segment", perm: "orders:*", required: "orders:read", want: true},
+		{name: "star does not match two segments", perm: "orders:*", required: "orders:read:own", want: false},
+		{name: "star does not match zero segments", perm: "orders:*:own", required: "orders:own", want: false},
+		{name: "middle star", perm: "orders:*:own", required: "orders:read:own", want: true},
+		{name: "double star matches one segment", perm: "orders:**", required: "orders:read", want: true},
+		{name: "double star matches several segments", perm: "orders:**", required: "orders:read:own", want: true},
+		{name: "double star without following segment", perm: "orders:**", required: "orders", want: false},
+		{name: "double star other prefix", perm: "orders:**", required: "billing:read", want: false},
+		{name: "star then double star", perm: "*:read:**", required: "orders:read:own:all", want: true},
+		{name: "star then double star mismatch", perm: "*:read:**", required: "orders:write:own", want: false},
+		{name: "bare star grants all", perm: "*", required: "orders:read:own", want: true},
+		{name: "bare double star grants nothing", perm: "**", required: "orders:read", want: false},
+		{name: "bare double star single segment", perm: "**", required: "orders", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasPermission([]string{tt.perm}, tt.required); got != tt.want {
+				t.Errorf("hasPermission([%q], %q) = %v, want %v", tt.perm, tt.required, got, tt.want)
+			}
+			_, reason := comparePermissionSegments(tt.perm, tt.required)
+			if got := reason == ""; got != tt.want && tt.perm != "*" {
+				t.Errorf("comparePermissionSegments(%q, %q) reason = %q, want match %v", tt.perm, tt.required, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePermissionWildcards(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "*"},
+		{in: "orders:*"},
+		{in: "orders:**"},
+		{in: "*:read:**"},
+		{in: "**", wantErr: true},
+		{in: "orders:**:own", wantErr: true},
+		{in: "orders:re*d", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := ParsePermission(tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("ParsePermission(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 部署模式（Config.RedisMode）
+const (
+	RedisModeSingle   = "single"   // 單節點（預設）
+	RedisModeCluster  = "cluster"  // Redis Cluster
+	RedisModeSentinel = "sentinel" // Sentinel 高可用
+)
+
+// newRedisClient 依 RedisMode 建立對應的 Redis 客戶端
+func newRedisClient(config *Config) (redis.UniversalClient, error) {
+	switch config.RedisMode {
+	case "", RedisModeSingle:
+		return redis.NewClient(&redis.Options{
+			Addr:     config.RedisAddr,
+			Password: config.RedisPassword,
+			DB:       config.RedisDB,
+		}), nil
+
+	case RedisModeCluster:
+		if config.RedisDB != 0 {
+			return nil, errors.New("redis cluster does not support selecting a database")
+		}
+		addrs, err := redisAddrs(config)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    addrs,
+			Password: config.RedisPassword,
+		}), nil
+
+	case RedisModeSentinel:
+		if config.RedisMasterName == "" {
+			return nil, errors.New("redis sentinel mode requires RedisMasterName")
+		}
+		addrs, err := redisAddrs(config)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.RedisMasterName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: config.RedisSentinelPassword,
+			Password:         config.RedisPassword,
+			DB:               config.RedisDB,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported redis mode %q", config.RedisMode)
+	}
+}
+
+// redisAddrs 回傳叢集節點或 Sentinel 地址，未設定 RedisAddrs 時沿用 RedisAddr
+func redisAddrs(config *Config) ([]string, error) {
+	if len(config.RedisAddrs) > 0 {
+		return config.RedisAddrs, nil
+	}
+	if config.RedisAddr != "" {
+		return []string{config.RedisAddr}, nil
+	}
+	return nil, fmt.Errorf("redis %s mode requires RedisAddrs", config.RedisMode)
+}
@@ -0,0 +1,45 @@
+package auth
+
+// RedisKeys 組成 SDK 讀寫的 Redis key，Prefix 會加在每個 key 之前（如 "portal:" → portal:user:status:{user_id}）
+// 營運工具直接檢查 Redis 時請使用此處的函式，避免與 SDK 的 key 格式不一致
+//
+//	keys := auth.RedisKeys{Prefix: "portal:"}
+//	rdb.Get(ctx, keys.UserStatus("42"))
+type RedisKeys struct {
+	Prefix string
+}
+
+// UserStatus 用戶狀態的 key：{prefix}user:status:{user_id}
+func (k RedisKeys) UserStatus(userID string) string {
+	return k.Prefix + "user:status:" + userID
+}
+
+// ForceLogout 強制登出標記的 key：{prefix}user:force_logout:{user_id}
+func (k RedisKeys) ForceLogout(userID string) string {
+	return k.Prefix + "user:force_logout:" + userID
+}
+
+// DynamicPermissions 動態權限的 key：{prefix}user:dynamic_permissions:{user_id}
+func (k RedisKeys) DynamicPermissions(userID string) string {
+	return k.Prefix + "user:dynamic_permissions:" + userID
+}
+
+// ConsumedToken 已使用的一次性 token 標記 key：{prefix}token:consumed:{jti}
+func (k RedisKeys) ConsumedToken(tokenID string) string {
+	return k.Prefix + "token:consumed:" + tokenID
+}
+
+// UserStatusKey 未設定前綴時用戶狀態的 key，設定了 Config.RedisKeyPrefix 時請改用 RedisKeys
+func UserStatusKey(userID string) string {
+	return RedisKeys{}.UserStatus(userID)
+}
+
+// ForceLogoutKey 未設定前綴時強制登出標記的 key，設定了 Config.RedisKeyPrefix 時請改用 RedisKeys
+func ForceLogoutKey(userID string) string {
+	return RedisKeys{}.ForceLogout(userID)
+}
+
+// DynamicPermissionsKey 未設定前綴時動態權限的 key，設定了 Config.RedisKeyPrefix 時請改用 RedisKeys
+func DynamicPermissionsKey(userID string) string {
+	return RedisKeys{}.DynamicPermissions(userID)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRedisKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "user status", got: RedisKeys{}.UserStatus("42"), want: "user:status:42"},
+		{name: "force logout", got: RedisKeys{}.ForceLogout("42"), want: "user:force_logout:42"},
+		{name: "dynamic permissions", got: RedisKeys{}.DynamicPermissions("42"), want: "user:dynamic_permissions:42"},
+		{name: "consumed token", got: RedisKeys{}.ConsumedToken("abc"), want: "token:consumed:abc"},
+		{name: "prefixed user status", got: RedisKeys{Prefix: "portal:"}.UserStatus("42"), want: "portal:user:status:42"},
+		{name: "prefixed force logout", got: RedisKeys{Prefix: "portal:"}.ForceLogout("42"), want: "portal:user:force_logout:42"},
+		{name: "prefixed dynamic permissions", got: RedisKeys{Prefix: "portal:"}.DynamicPermissions("42"), want: "portal:user:dynamic_permissions:42"},
+		{name: "prefixed consumed token", got: RedisKeys{Prefix: "portal:"}.ConsumedToken("abc"), want: "portal:token:consumed:abc"},
+		{name: "legacy user status", got: UserStatusKey("42"), want: "user:status:42"},
+		{name: "legacy force logout", got: ForceLogoutKey("42"), want: "user:force_logout:42"},
+		{name: "legacy dynamic permissions", got: DynamicPermissionsKey("42"), want: "user:dynamic_permissions:42"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s key = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestClientUsesRedisKeyPrefix(t *testing.T) {
+	for name, prefix := range map[string]string{"no prefix": "", "with prefix": "portal:"} {
+		t.Run(name, func(t *testing.T) {
+			server, redisClient := newTestRedis(t)
+			client := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t), RedisClient: redisClient, RedisKeyPrefix: prefix})
+			ctx := context.Background()
+
+			if err := client.SetUserStatus(ctx, "42", false); err != nil {
+				t.Fatalf("SetUserStatus: %v", err)
+			}
+			if err := client.SetForceLogout(ctx, "42"); err != nil {
+				t.Fatalf("SetForceLogout: %v", err)
+			}
+			server.Set(prefix+"user:dynamic_permissions:42", `["orders:read"]`)
+
+			for _, key := range []string{prefix + "user:status:42", prefix + "user:force_logout:42"} {
+				if !server.Exists(key) {
+					t.Errorf("key %q not written, keys %v", key, server.Keys())
+				}
+			}
+			if len(server.Keys()) != 3 {
+				t.Errorf("keys = %v, want only the prefixed keys", server.Keys())
+			}
+			permissions, err := client.GetUserDynamicPermissions(ctx, "42")
+			if err != nil || len(permissions) != 1 || permissions[0] != "orders:read" {
+				t.Errorf("GetUserDynamicPermissions = %v, %v; want [orders:read]", permissions, err)
+			}
+			if got := client.RedisKeys().UserStatus("42"); got != prefix+"user:status:42" {
+				t.Errorf("RedisKeys().UserStatus = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// RedisStoreConfig Redis 儲存設定
+type RedisStoreConfig struct {
+	// ReadClient 查詢用的 Redis 客戶端（如唯讀副本），為 nil 時與寫入共用同一客戶端
+	ReadClient redis.Cmdable
+	// DeleteCorruptCache 內容無法解析時是否刪除該 key，讓上游重新寫入
+	DeleteCorruptCache bool
+	// RewritePermissionsOnRead 讀到非標準格式（純陣列、逗號分隔字串等）的動態權限時，
+	// 是否以標準的包裝物件格式寫回並保留原 TTL；預設關閉，避免讀取時產生非預期的寫入
+	RewritePermissionsOnRead bool
+	// StatusTTL 用戶狀態的存活時間，預設 10 分鐘
+	StatusTTL time.Duration
+	// ForceLogoutTTL 強制登出標記的存活時間，預設 24 小時
+	ForceLogoutTTL time.Duration
+	// KeyPrefix 所有 key 的前綴（見 RedisKeys），多個服務共用同一 Redis 時用於區隔
+	KeyPrefix string
+	Logger    *zap.Logger
+}
+
+// RedisStore 以 Redis 實作的 PermissionStore
+//
+//	{prefix}user:status:{user_id}              → {"is_active": true, "updated_at": "..."}
+//	{prefix}user:force_logout:{user_id}        → Unix 秒
+//	{prefix}user:dynamic_permissions:{user_id} → 見 parsePermissionsCache
+type RedisStore struct {
+	client     redis.Cmdable
+	readClient redis.Cmdable
+	config     RedisStoreConfig
+	keys       RedisKeys
+	logger     *zap.Logger
+}
+
+// NewRedisStore 建立 Redis 儲存
+func NewRedisStore(client redis.Cmdable, config RedisStoreConfig) *RedisStore {
+	if config.StatusTTL <= 0 {
+		config.StatusTTL = 10 * time.Minute
+	}
+	if config.ForceLogoutTTL <= 0 {
+		config.ForceLogoutTTL = 24 * time.Hour
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	}
+
+	readClient := config.ReadClient
+	if readClient == nil {
+		readClient = client
+	}
+
+	return &RedisStore{
+		client:     client,
+		readClient: readClient,
+		config:     config,
+		keys:       RedisKeys{Prefix: config.KeyPrefix},
+		logger:     config.Logger,
+	}
+}
+
+// GetUserStatus 取得用戶狀態
+func (s *RedisStore) GetUserStatus(ctx context.Context, userID string) (*UserStatus, error) {
+	key := s.keys.UserStatus(userID)
+
+	val, err := s.readClient.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrCacheNotFound
+		}
+		return nil, err
+	}
+
+	var status UserStatus
+	if err := json.Unmarshal([]byte(val), &status); err != nil {
+		return nil, s.handleCorruptCache(ctx, key, err)
+	}
+
+	return &status, nil
+}
+
+// GetUserStatuses 以單次 pipeline 批次取得多個用戶的狀態
+func (s *RedisStore) GetUserStatuses(ctx context.Context, userIDs []string) (map[string]*UserStatus, error) {
+	statuses := make(map[string]*UserStatus, len(userIDs))
+	if len(userIDs) == 0 {
+		return statuses, nil
+	}
+
+	keys, cmds, err := s.pipelineGet(ctx, userIDs, s.keys.UserStatus)
+	if err != nil {
+		return statuses, err
+	}
+
+	var errs []error
+	for i, userID := range userIDs {
+		val, err := cmds[i].Result()
+		if err != nil {
+			if err == redis.Nil {
+				statuses[userID] = nil
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", userID, err))
+			continue
+		}
+
+		var status UserStatus
+		if err := json.Unmarshal([]byte(val), &status); err != nil {
+			errs = append(errs, s.handleCorruptCache(ctx, keys[i], err))
+			continue
+		}
+		statuses[userID] = &status
+	}
+
+	return statuses, errors.Join(errs...)
+}
+
+// storedUserStatus 寫入 Redis 的用戶狀態，額外帶上以微秒表示的 UpdatedAt 供 Lua 腳本比較
+// （RFC 3339 字串的時區與小數位數不固定，無法在腳本中直接比較）
+type storedUserStatus struct {
+	UserStatus
+	Version int64 `json:"version"`
+}
+
+// setUserStatusScript 僅在新狀態的 version 大於現有狀態時寫入，回傳是否寫入
+// 現有狀態不存在、無法解析或未帶 version（如其他服務寫入）時直接覆蓋
+var setUserStatusScript = redis.NewScript(`
+local raw = redis.call("GET", KEYS[1])
+if raw then
+	local ok, current = pcall(cjson.decode, raw)
+	if ok and type(current) == "table" and tonumber(current.version) and tonumber(current.version) >= tonumber(ARGV[2]) then
+		return 0
+	end
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// SetUserStatus 設置用戶狀態，僅在 UpdatedAt 晚於現有狀態時寫入，否則回傳 ErrStaleUserStatus
+// 避免停用後，其他程序以較舊的資料重新寫入快取而使用戶被重新啟用
+func (s *RedisStore) SetUserStatus(ctx context.Context, userID string, status UserStatus) error {
+	stored := storedUserStatus{UserStatus: status, Version: status.UpdatedAt.UnixMicro()}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user status: %w", err)
+	}
+
+	written, err := setUserStatusScript.Run(ctx, s.client, []string{s.keys.UserStatus(userID)},
+		string(data), stored.Version, s.config.StatusTTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		return ErrStaleUserStatus
+	}
+	return nil
+}
+
+// MarkTokenConsumed 以 SETNX 標記一次性 token 已使用
+func (s *RedisStore) MarkTokenConsumed(ctx context.Context, tokenID string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.keys.ConsumedToken(tokenID), time.Now().Unix(), ttl).Result()
+}
+
+// Ping 檢查寫入與查詢用的 Redis 連線
+func (s *RedisStore) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	if s.readClient != s.client {
+		if err := s.readClient.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("read client: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetForceLogout 取得強制登出時間
+func (s *RedisStore) GetForceLogout(ctx context.Context, userID string) (int64, error) {
+	key := s.keys.ForceLogout(userID)
+
+	val, err := s.readClient.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, ErrCacheNotFound
+		}
+		return 0, err
+	}
+
+	timestamp, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, s.handleCorruptCache(ctx, key, err)
+	}
+
+	return timestamp, nil
+}
+
+// setForceLogoutScript 僅在新時間晚於現有標記（或標記不存在、無法解析）時寫入，回傳是否寫入
+// 避免時鐘偏移的節點或重試以較早的時間覆蓋，縮小強制登出的失效範圍
+var setForceLogoutScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]))
+if current and current >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// SetForceLogout 設置強制登出時間，已存在較晚（或相同）的標記時保持不變
+func (s *RedisStore) SetForceLogout(ctx context.Context, userID string, timestamp int64) error {
+	key := s.keys.ForceLogout(userID)
+	written, err := setForceLogoutScript.Run(ctx, s.client, []string{key},
+		timestamp, s.config.ForceLogoutTTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		s.logger.Debug("Force logout timestamp not moved backward",
+			zap.String("key", key), zap.Int64("timestamp", timestamp))
+	}
+	return nil
+}
+
+// ClearForceLogout 清除強制登出標記
+func (s *RedisStore) ClearForceLogout(ctx context.Context, userID string) error {
+	return s.client.Del(ctx, s.keys.ForceLogout(userID)).Err()
+}
+
+// GetDynamicPermissions 取得動態權限（兼容包裝物件、純陣列與逗號分隔字串）
+func (s *RedisStore) GetDynamicPermissions(ctx context.Context, userID string) ([]string, error) {
+	key := s.keys.DynamicPermissions(userID)
+
+	val, err := s.readClient.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrCacheNotFound
+		}
+		return nil, err
+	}
+
+	permissions, err := parsePermissionsCache(val)
+	if err != nil {
+		return nil, s.handleCorruptCache(ctx, key, err)
+	}
+	s.rewritePermissionsCache(ctx, key, val)
+
+	return permissions, nil
+}
+
+// GetDynamicPermissionsBatch 以單次 pipeline 批次取得多個用戶的動態權限
+// 使用 pipeline 而非 MGET：叢集模式下各 key 可能位於不同 slot
+func (s *RedisStore) GetDynamicPermissionsBatch(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	keys, cmds, err := s.pipelineGet(ctx, userIDs, s.keys.DynamicPermissions)
+	if err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for i, userID := range userIDs {
+		val, err := cmds[i].Result()
+		if err != nil {
+			if err == redis.Nil {
+				result[userID] = nil
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", userID, err))
+			continue
+		}
+
+		// 單筆解析失敗不影響其他用戶
+		permissions, err := parsePermissionsCache(val)
+		if err != nil {
+			_ = s.handleCorruptCache(ctx, keys[i], err)
+			result[userID] = nil
+			continue
+		}
+		result[userID] = permissions
+		s.rewritePermissionsCache(ctx, keys[i], val)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// GetDynamicPermissionsUpdatedAt 取得動態權限的寫入時間
+func (s *RedisStore) GetDynamicPermissionsUpdatedAt(ctx context.Context, userID string) (time.Time, error) {
+	val, err := s.readClient.Get(ctx, s.keys.DynamicPermissions(userID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, ErrCacheNotFound
+		}
+		return time.Time{}, err
+	}
+
+	return parsePermissionsCacheTimestamp(val)
+}
+
+// rewritePermissionsScript 僅在內容仍為讀取時的值時寫入新內容，並沿用原本的剩餘 TTL
+var rewritePermissionsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ttl)
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// rewritePermissionsCache 啟用 RewritePermissionsOnRead 時將非標準格式的動態權限寫回為標準格式
+// 以腳本比對原內容，期間上游已寫入新值時不覆蓋；寫入失敗只記錄，不影響本次讀取
+func (s *RedisStore) rewritePermissionsCache(ctx context.Context, key, val string) {
+	if !s.config.RewritePermissionsOnRead {
+		return
+	}
+	canonical, ok := canonicalPermissionsCache(val)
+	if !ok {
+		return
+	}
+
+	if err := rewritePermissionsScript.Run(ctx, s.client, []string{key}, val, canonical).Err(); err != nil {
+		s.logger.Warn("Failed to rewrite permissions cache in canonical format",
+			zap.String("key", key), zap.Error(err))
+		return
+	}
+	s.logger.Debug("Rewrote permissions cache in canonical format", zap.String("key", key))
+}
+
+// pipelineGet 以單次 pipeline 讀取多個用戶的 key，個別指令的錯誤由呼叫端逐一處理
+// 連線失敗等導致整個 pipeline 未執行時回傳錯誤，此時各指令不帶錯誤，不可當作空值
+func (s *RedisStore) pipelineGet(ctx context.Context, userIDs []string, keyFunc func(string) string) ([]string, []*redis.StringCmd, error) {
+	keys := make([]string, len(userIDs))
+	cmds := make([]*redis.StringCmd, len(userIDs))
+	pipe := s.readClient.Pipeline()
+	for i, userID := range userIDs {
+		keys[i] = keyFunc(userID)
+		cmds[i] = pipe.Get(ctx, keys[i])
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		var replyErr redis.Error
+		if !errors.As(err, &replyErr) {
+			return nil, nil, err
+		}
+	}
+	return keys, cmds, nil
+}
+
+// handleCorruptCache 統一處理無法解析的快取內容
+// 記錄警告、依設定刪除損壞的 key，並回傳包裝 ErrCorruptCache 的錯誤
+func (s *RedisStore) handleCorruptCache(ctx context.Context, key string, parseErr error) error {
+	s.logger.Warn("Corrupt cache entry, falling back to default",
+		zap.String("key", key), zap.Error(parseErr))
+
+	if s.config.DeleteCorruptCache {
+		if err := s.client.Del(ctx, key).Err(); err != nil {
+			s.logger.Warn("Failed to delete corrupt cache entry",
+				zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	return fmt.Errorf("%w: %s: %v", ErrCorruptCache, key, parseErr)
+}
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTestRedis 啟動測試用的 miniredis 並回傳連線至該伺服器的客戶端
+func newTestRedis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestRedisStoreCorruptCache(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		val  string
+		get  func(*RedisStore) error
+	}{
+		{
+			name: "user status malformed json",
+			key:  "user:status:42",
+			val:  `{"is_active": tru`,
+			get: func(s *RedisStore) error {
+				_, err := s.GetUserStatus(context.Background(), "42")
+				return err
+			},
+		},
+		{
+			name: "user status wrong type",
+			key:  "user:status:42",
+			val:  `{"is_active": "yes"}`,
+			get: func(s *RedisStore) error {
+				_, err := s.GetUserStatus(context.Background(), "42")
+				return err
+			},
+		},
+		{
+			name: "force logout not a number",
+			key:  "user:force_logout:42",
+			val:  "yesterday",
+			get: func(s *RedisStore) error {
+				_, err := s.GetForceLogout(context.Background(), "42")
+				return err
+			},
+		},
+		{
+			name: "dynamic permissions malformed json",
+			key:  "user:dynamic_permissions:42",
+			val:  `["user:read",`,
+			get: func(s *RedisStore) error {
+				_, err := s.GetDynamicPermissions(context.Background(), "42")
+				return err
+			},
+		},
+		{
+			name: "dynamic permissions missing field",
+			key:  "user:dynamic_permissions:42",
+			val:  `{"perms": ["user:read"]}`,
+			get: func(s *RedisStore) error {
+				_, err := s.GetDynamicPermissions(context.Background(), "42")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, deleteCorrupt := range []bool{false, true} {
+			t.Run(tt.name, func(t *testing.T) {
+				server, client := newTestRedis(t)
+				server.Set(tt.key, tt.val)
+
+				core, logs := observer.New(zapcore.WarnLevel)
+				store := NewRedisStore(client, RedisStoreConfig{
+					DeleteCorruptCache: deleteCorrupt,
+					Logger:             zap.New(core),
+				})
+
+				err := tt.get(store)
+				if !errors.Is(err, ErrCorruptCache) {
+					t.Fatalf("err = %v, want ErrCorruptCache", err)
+				}
+				if got := logs.FilterMessage("Corrupt cache entry, falling back to default").Len(); got != 1 {
+					t.Errorf("corrupt cache warnings = %d, want 1", got)
+				}
+				if exists := server.Exists(tt.key); exists == deleteCorrupt {
+					t.Errorf("key exists = %v with DeleteCorruptCache = %v", exists, deleteCorrupt)
+				}
+			})
+		}
+	}
+}
+
+func TestRedisStoreMissingKey(t *testing.T) {
+	_, client := newTestRedis(t)
+	store := NewRedisStore(client, RedisStoreConfig{})
+	ctx := context.Background()
+
+	if _, err := store.GetUserStatus(ctx, "42"); !errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetUserStatus err = %v, want ErrCacheNotFound", err)
+	}
+	if _, err := store.GetForceLogout(ctx, "42"); !errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetForceLogout err = %v, want ErrCacheNotFound", err)
+	}
+	if _, err := store.GetDynamicPermissions(ctx, "42"); !errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("GetDynamicPermissions err = %v, want ErrCacheNotFound", err)
+	}
+}
+
+// singlePermissionStore 隱藏 BatchPermissionStore，使 Client 改為逐一查詢
+type singlePermissionStore struct {
+	PermissionStore
+}
+
+func TestGetUserDynamicPermissionsBatch(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:dynamic_permissions:1", `["user:read","user:write"]`)
+	server.Set("user:dynamic_permissions:3", `["user:read",`)
+	server.Set("user:dynamic_permissions:4", `user:read,order:*`)
+
+	redisStore := NewRedisStore(redisClient, RedisStoreConfig{Logger: zap.NewNop()})
+
+	tests := []struct {
+		name  string
+		store PermissionStore
+	}{
+		{name: "pipelined batch", store: redisStore},
+		{name: "per-user fallback", store: singlePermissionStore{redisStore}},
+	}
+
+	want := map[string][]string{
+		"1": {"user:read", "user:write"},
+		"2": nil,
+		"3": nil,
+		"4": {"user:read", "order:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, &Config{Store: tt.store, PublicKeyPath: mustRSAKeyPath(t)})
+
+			got, err := client.GetUserDynamicPermissionsBatch(context.Background(), []string{"1", "2", "3", "4"})
+			if err != nil {
+				t.Fatalf("GetUserDynamicPermissionsBatch: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d entries, want %d: %v", len(got), len(want), got)
+			}
+			for userID, wantPerms := range want {
+				gotPerms, ok := got[userID]
+				if !ok {
+					t.Errorf("user %s missing from result", userID)
+					continue
+				}
+				if fmt.Sprint(gotPerms) != fmt.Sprint(wantPerms) || (gotPerms == nil) != (wantPerms == nil) {
+					t.Errorf("user %s = %v, want %v", userID, gotPerms, wantPerms)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserDynamicPermissionsBatchEmpty(t *testing.T) {
+	client := newTestClient(t, &Config{PublicKeyPath: mustRSAKeyPath(t)})
+
+	got, err := client.GetUserDynamicPermissionsBatch(context.Background(), nil)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("GetUserDynamicPermissionsBatch(nil) = %v, %v; want empty map", got, err)
+	}
+}
+
+func TestGetUserStatusesBatch(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:status:1", `{"is_active":true}`)
+	server.Set("user:status:3", `{"is_active":`)
+	store := NewRedisStore(redisClient, RedisStoreConfig{Logger: zap.NewNop()})
+
+	got, err := store.GetUserStatuses(context.Background(), []string{"1", "2", "3"})
+	if !errors.Is(err, ErrCorruptCache) {
+		t.Errorf("err = %v, want ErrCorruptCache for user 3", err)
+	}
+	if got["1"] == nil || !got["1"].IsActive {
+		t.Errorf("user 1 = %+v, want active", got["1"])
+	}
+	if status, ok := got["2"]; !ok || status != nil {
+		t.Errorf("user 2 = %+v (present %v), want nil entry", status, ok)
+	}
+	if _, ok := got["3"]; ok {
+		t.Errorf("user 3 present in result despite corrupt cache")
+	}
+}
+
+func TestRedisStoreBatchRedisDown(t *testing.T) {
+	server, redisClient := newTestRedis(t)
+	server.Set("user:dynamic_permissions:1", `["user:read"]`)
+	store := NewRedisStore(redisClient, RedisStoreConfig{Logger: zap.NewNop()})
+	server.Close()
+
+	permissions, err := store.GetDynamicPermissionsBatch(context.Background(), []string{"1", "2"})
+	if err == nil || len(permissions) != 0 {
+		t.Errorf("GetDynamicPermissionsBatch = %v, %v; want no entries and an error", permissions, err)
+	}
+	statuses, err := store.GetUserStatuses(context.Background(), []string{"1", "2"})
+	if err == nil || len(statuses) != 0 {
+		t.Errorf("GetUserStatuses = %v, %v; want no entries and an error", statuses, err)
+	}
+}
+
+func TestRedisStoreSetForceLogoutNeverMovesBackward(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // 空字串表示 key 不存在
+		write    int64
+		want     string
+		written  bool
+	}{
+		{name: "no existing flag", existing: "", write: 1000, want: "1000", written: true},
+		{name: "older write ignored", existing: "1000", write: 999, want: "1000"},
+		{name: "equal write ignored", existing: "1000", write: 1000, want: "1000"},
+		{name: "newer write wins", existing: "1000", write: 1001, want: "1001", written: true},
+		{name: "corrupt flag overwritten", existing: "yesterday", write: 1000, want: "1000", written: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newTestRedis(t)
+			store := NewRedisStore(client, RedisStoreConfig{ForceLogoutTTL: time.Hour})
+			const key = "user:force_logout:42"
+			if tt.existing != "" {
+				server.Set(key, tt.existing)
+			}
+
+			if err := store.SetForceLogout(context.Background(), "42", tt.write); err != nil {
+				t.Fatalf("SetForceLogout: %v", err)
+			}
+
+			got, err := server.Get(key)
+			if err != nil || got != tt.want {
+				t.Fatalf("stored = %q, %v; want %q", got, err, tt.want)
+			}
+			if tt.written && server.TTL(key) != time.Hour {
+				t.Errorf("TTL = %v, want %v", server.TTL(key), time.Hour)
+			}
+		})
+	}
+}
+
+func TestRedisStoreSetUserStatusOutOfOrder(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const key = "user:status:42"
+
+	tests := []struct {
+		name       string
+		existing   string // 空字串表示 key 不存在
+		write      UserStatus
+		wantErr    error
+		wantActive bool
+	}{
+		{
+			name:       "no existing status",
+			write:      UserStatus{IsActive: true, UpdatedAt: base},
+			wantActive: true,
+		},
+		{
+			name:       "stale write rejected",
+			existing:   fmt.Sprintf(`{"is_active":false,"version":%d}`, base.UnixMicro()),
+			write:      UserStatus{IsActive: true, UpdatedAt: base.Add(-time.Second)},
+			wantErr:    ErrStaleUserStatus,
+			wantActive: false,
+		},
+		{
+			name:       "same version rejected",
+			existing:   fmt.Sprintf(`{"is_active":false,"version":%d}`, base.UnixMicro()),
+			write:      UserStatus{IsActive: true, UpdatedAt: base},
+			wantErr:    ErrStaleUserStatus,
+			wantActive: false,
+		},
+		{
+			name:       "newer write wins",
+			existing:   fmt.Sprintf(`{"is_active":true,"version":%d}`, base.UnixMicro()),
+			write:      UserStatus{IsActive: false, UpdatedAt: base.Add(time.Microsecond)},
+			wantActive: false,
+		},
+		{
+			name:       "legacy value without version overwritten",
+			existing:   `{"is_active":false,"updated_at":"2030-01-01T00:00:00Z"}`,
+			write:      UserStatus{IsActive: true, UpdatedAt: base},
+			wantActive: true,
+		},
+		{
+			name:       "corrupt value overwritten",
+			existing:   `{"is_active":`,
+			write:      UserStatus{IsActive: true, UpdatedAt: base},
+			wantActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newTestRedis(t)
+			store := NewRedisStore(client, RedisStoreConfig{})
+			if tt.existing != "" {
+				server.Set(key, tt.existing)
+			}
+
+			err := store.SetUserStatus(context.Background(), "42", tt.write)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetUserStatus err = %v, want %v", err, tt.wantErr)
+			}
+
+			status, err := store.GetUserStatus(context.Background(), "42")
+			if err != nil {
+				t.Fatalf("GetUserStatus: %v", err)
+			}
+			if status.IsActive != tt.wantActive {
+				t.Errorf("IsActive = %v, want %v", status.IsActive, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestRedisStoreSetUserStatusDisableNotUndoneByStaleRefresh(t *testing.T) {
+	_, client := newTestRedis(t)
+	store := NewRedisStore(client, RedisStoreConfig{})
+	ctx := context.Background()
+	readAt := time.Now()
+
+	// 管理員停用用戶後，另一程序以停用前讀到的資料回寫快取
+	if err := store.SetUserStatus(ctx, "42", UserStatus{IsActive: false, UpdatedAt: readAt.Add(time.Second)}); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if err := store.SetUserStatus(ctx, "42", UserStatus{IsActive: true, UpdatedAt: readAt}); !errors.Is(err, ErrStaleUserStatus) {
+		t.Fatalf("stale refresh err = %v, want ErrStaleUserStatus", err)
+	}
+
+	status, err := store.GetUserStatus(ctx, "42")
+	if err != nil || status.IsActive {
+		t.Fatalf("GetUserStatus = %+v, %v; want the user to stay disabled", status, err)
+	}
+}
@@ -1,20 +1,26 @@
 package response
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 // APIResponse 統一的 API 響應格式
 type APIResponse struct {
-	Success   bool        `json:"success"`
-	Data      interface{} `json:"data,omitempty"`
-	Error     *ErrorInfo  `json:"error,omitempty"`
-	Message   string      `json:"message,omitempty"`
-	Timestamp int64       `json:"timestamp"`
-	RequestID string      `json:"request_id,omitempty"`
+	Success    bool        `json:"success"`
+	Data       interface{} `json:"data,omitempty"`
+	Error      *ErrorInfo  `json:"error,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`   // 非致命的警告，如使用了已棄用的欄位
+	Pagination *Pagination `json:"pagination,omitempty"` // 列表分頁資訊，見 Paginated
+	Timestamp  int64       `json:"timestamp"`
+	RequestID  string      `json:"request_id,omitempty"`
 }
 
 // ErrorInfo 错誤信息結構
@@ -24,6 +30,14 @@ type ErrorInfo struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// Pagination 分頁資訊
+type Pagination struct {
+	Page       int   `json:"page"`        // 目前頁數（從 1 開始）
+	PageSize   int   `json:"page_size"`   // 每頁筆數
+	Total      int64 `json:"total"`       // 總筆數
+	TotalPages int64 `json:"total_pages"` // 總頁數
+}
+
 // Success 返回成功響應
 func Success(c *gin.Context, data interface{}, message ...string) {
 	response := APIResponse{
@@ -40,11 +54,77 @@ func Success(c *gin.Context, data interface{}, message ...string) {
 	c.JSON(http.StatusOK, response)
 }
 
-// Error 返回错誤響應
+// Created 返回 201 建立成功響應，data 通常為新建立的資源
+func Created(c *gin.Context, data interface{}, message ...string) {
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+		RequestID: getRequestID(c),
+	}
+
+	if len(message) > 0 {
+		response.Message = message[0]
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// NoContent 返回 204 無內容響應（不含響應主體）
+func NoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// SuccessWithWarnings 返回帶有警告的成功響應，warnings 為空時與 Success 相同
+func SuccessWithWarnings(c *gin.Context, data interface{}, warnings []string, message ...string) {
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Warnings:  warnings,
+		Timestamp: time.Now().Unix(),
+		RequestID: getRequestID(c),
+	}
+
+	if len(message) > 0 {
+		response.Message = message[0]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Paginated 返回帶有分頁資訊的成功響應，data 為目前頁的資料
+// pageSize 小於等於 0 時 total_pages 為 0
+func Paginated(c *gin.Context, data interface{}, page, pageSize int, total int64, message ...string) {
+	var totalPages int64
+	if pageSize > 0 {
+		totalPages = (total + int64(pageSize) - 1) / int64(pageSize)
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    data,
+		Pagination: &Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+		Timestamp: time.Now().Unix(),
+		RequestID: getRequestID(c),
+	}
+
+	if len(message) > 0 {
+		response.Message = message[0]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Error 返回错誤響應，設定了 SetTranslator 時 message 會依代碼翻譯
 func Error(c *gin.Context, statusCode int, code, message string, details ...interface{}) {
 	errorInfo := &ErrorInfo{
 		Code:    code,
-		Message: message,
+		Message: Translate(c, code, message),
 	}
 
 	if len(details) > 0 {
@@ -66,6 +146,72 @@ func BadRequest(c *gin.Context, message string, details ...interface{}) {
 	Error(c, http.StatusBadRequest, "BAD_REQUEST", message, details...)
 }
 
+// ValidationError 返回 422 错誤，details 為欄位名稱對應的错誤訊息，供前端逐欄顯示
+//
+//	if err := c.ShouldBindJSON(&req); err != nil {
+//		if fields, ok := response.FieldErrors(err); ok {
+//			response.ValidationError(c, fields)
+//			return
+//		}
+//		response.BadRequest(c, "Invalid request body")
+//		return
+//	}
+func ValidationError(c *gin.Context, fields map[string]string) {
+	Error(c, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Validation failed", fields)
+}
+
+// FieldErrors 將 validator.ValidationErrors（gin binding 的驗證错誤）轉為欄位名稱對應错誤訊息的 map
+// 欄位名稱為去除最外層結構名稱的路徑，如 Items[0].Name；err 不是驗證错誤（如 JSON 格式错誤）時回傳 false
+// 預設為 Go 欄位名稱，需要 JSON 欄位名稱時請於 binding.Validator.Engine() 註冊 RegisterTagNameFunc
+func FieldErrors(err error) (map[string]string, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fe := range validationErrors {
+		field := fe.Namespace()
+		if _, rest, found := strings.Cut(field, "."); found {
+			field = rest
+		}
+		fields[field] = fieldErrorMessage(fe)
+	}
+	return fields, true
+}
+
+// fieldErrorMessage 依驗證規則產生欄位错誤訊息
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	case "oneof":
+		return "must be one of [" + strings.Join(strings.Fields(fe.Param()), ", ") + "]"
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("must be %s %s", comparisonWords[fe.Tag()], fe.Param())
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed on the '%s=%s' rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+}
+
+// comparisonWords 比較規則的描述
+var comparisonWords = map[string]string{
+	"gt":  "greater than",
+	"gte": "greater than or equal to",
+	"lt":  "less than",
+	"lte": "less than or equal to",
+}
+
 // Unauthorized 返回 401 错誤
 func Unauthorized(c *gin.Context, message string, details ...interface{}) {
 	Error(c, http.StatusUnauthorized, "UNAUTHORIZED", message, details...)
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Translator 依错誤代碼（如 UNAUTHORIZED）回傳本地化訊息，沒有對應翻譯時回傳空字串
+type Translator func(c *gin.Context, code string) string
+
+// translator 目前設定的 Translator
+var translator atomic.Pointer[Translator]
+
+// SetTranslator 設定错誤訊息的翻譯函式，之後 Error 及其衍生函式（BadRequest、Unauthorized 等）
+// 與 auth 中介軟體的错誤回應會以翻譯結果取代原訊息；傳入 nil 取消翻譯
+// 翻譯只依代碼決定，同一代碼的不同訊息會被同一翻譯取代；通常於程式啟動時設定一次
+//
+//	response.SetTranslator(func(c *gin.Context, code string) string {
+//		lang := response.PreferredLanguage(c, "zh-TW", "en")
+//		return messages[lang][code]
+//	})
+func SetTranslator(t Translator) {
+	if t == nil {
+		translator.Store(nil)
+		return
+	}
+	translator.Store(&t)
+}
+
+// Translate 以設定的 Translator 翻譯错誤代碼，未設定或沒有對應翻譯時回傳 fallback
+func Translate(c *gin.Context, code, fallback string) string {
+	t := translator.Load()
+	if t == nil {
+		return fallback
+	}
+	if message := (*t)(c, code); message != "" {
+		return message
+	}
+	return fallback
+}
+
+// PreferredLanguage 依 Accept-Language 的權重從 supported 中選出最適合的語言，都不符合時回傳 supported 的第一個
+// 比對不分大小寫，並接受主要語言相符者（如 en-US 對應 en）
+func PreferredLanguage(c *gin.Context, supported ...string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		accepted = append(accepted, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+
+	for _, a := range accepted {
+		if a.tag == "*" {
+			return supported[0]
+		}
+		for _, lang := range supported {
+			if strings.EqualFold(a.tag, lang) {
+				return lang
+			}
+		}
+		primary, _, _ := strings.Cut(a.tag, "-")
+		for _, lang := range supported {
+			if strings.EqualFold(primary, lang) {
+				return lang
+			}
+		}
+	}
+	return supported[0]
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RouteRule 路由授權規則
+type RouteRule struct {
+	Method      string   // HTTP 方法，為空時適用所有方法
+	Path        string   // 完整路徑，或以 /* 結尾的前綴（/admin/* 同時涵蓋 /admin）
+	Permissions []string // 需具備其中任一權限
+}
+
+// RouteAuthzConfig 路由授權設定
+type RouteAuthzConfig struct {
+	Rules []RouteRule // 依序比對，採用第一條符合的規則
+
+	// DenyUnmatched 沒有任何規則符合時拒絕請求（預設放行）
+	DenyUnmatched bool
+}
+
+// compiledRouteRule 已正規化的規則
+type compiledRouteRule struct {
+	method      string
+	path        string
+	prefix      bool
+	permissions []string
+}
+
+// RouteAuthz 依請求路徑集中套用授權規則，需搭配 Authenticate 使用；規則路徑含 . 或 .. 區段時 panic
+// 比對前會正規化請求路徑與規則路徑（合併重複斜線、移除結尾斜線），避免 //admin//users 或 /admin/users/ 等變形路徑繞過規則
+// 含 . 或 ..（包括 %2e%2e 編碼）區段的請求路徑一律回應 400：路由器不會解析這些區段，
+// 若在授權時解析，/admin/../public 會依 /public 授權卻仍由 /admin 的處理器處理
+func (m *GinMiddleware) RouteAuthz(config RouteAuthzConfig) gin.HandlerFunc {
+	rules := make([]compiledRouteRule, 0, len(config.Rules))
+	for _, rule := range config.Rules {
+		compiled := compiledRouteRule{
+			method:      strings.ToUpper(rule.Method),
+			permissions: rule.Permissions,
+		}
+		m.registry.addPermissions(rule.Permissions...)
+		rulePath := rule.Path
+		if p, ok := strings.CutSuffix(rule.Path, "/*"); ok {
+			compiled.prefix = true
+			rulePath = p
+		}
+		var ok bool
+		if compiled.path, ok = normalizePath(rulePath); !ok {
+			panic("auth: RouteAuthz rule path " + rule.Path + " contains . or .. segments")
+		}
+		rules = append(rules, compiled)
+	}
+
+	return func(c *gin.Context) {
+		requestPath, ok := normalizePath(c.Request.URL.Path)
+		if !ok {
+			m.logger.Warn("Rejected request path with dot segments",
+				zap.String("user_id", m.getUserID(c)),
+				zap.String("path", c.Request.URL.Path))
+			m.abortWithError(c, http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request path",
+				Error:   "BAD_REQUEST",
+			})
+			return
+		}
+
+		for _, rule := range rules {
+			if !rule.matches(c.Request.Method, requestPath) {
+				continue
+			}
+
+			userPermissions := contextStrings(c, ContextKeyPermissions)
+			for _, permission := range rule.permissions {
+				if hasPermission(userPermissions, permission) {
+					c.Next()
+					return
+				}
+			}
+
+			m.logger.Info("Route permission denied",
+				zap.String("user_id", m.getUserID(c)),
+				zap.String("path", requestPath),
+				zap.Strings("required_permissions", rule.permissions))
+			m.denyPermission(c, rule.permissions, "Insufficient permissions: required one of ["+strings.Join(rule.permissions, ", ")+"]")
+			return
+		}
+
+		if config.DenyUnmatched {
+			m.denyPermission(c, nil, "No authorization rule for this route")
+			return
+		}
+		c.Next()
+	}
+}
+
+// matches 判斷規則是否適用於請求
+func (r compiledRouteRule) matches(method, requestPath string) bool {
+	if r.method != "" && r.method != method {
+		return false
+	}
+	if !r.prefix {
+		return requestPath == r.path
+	}
+	if r.path == "/" {
+		return true
+	}
+	return requestPath == r.path || strings.HasPrefix(requestPath, r.path+"/")
+}
+
+// normalizePath 正規化路徑：確保以 / 開頭、合併重複斜線、移除結尾斜線
+// 不解析 . 與 ..，路徑含這類區段時回傳 false
+func normalizePath(p string) (string, bool) {
+	segments := strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+	for _, segment := range segments {
+		if segment == "." || segment == ".." {
+			return "", false
+		}
+	}
+	return "/" + strings.Join(segments, "/"), true
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{in: "", want: "/", wantOK: true},
+		{in: "/", want: "/", wantOK: true},
+		{in: "//", want: "/", wantOK: true},
+		{in: "admin/users", want: "/admin/users", wantOK: true},
+		{in: "/admin/users/", want: "/admin/users", wantOK: true},
+		{in: "//admin//users", want: "/admin/users", wantOK: true},
+		{in: "//admin//users//", want: "/admin/users", wantOK: true},
+		{in: "/admin/.well-known/..x", want: "/admin/.well-known/..x", wantOK: true},
+		{in: "/admin/./users"},
+		{in: "/public/../admin/users"},
+		{in: "/admin/../public"},
+		{in: "/admin/.."},
+		{in: "/../admin/users"},
+	}
+
+	for _, tt := range tests {
+		if got, ok := normalizePath(tt.in); got != tt.want || ok != tt.wantOK {
+			t.Errorf("normalizePath(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRouteAuthzPathVariants(t *testing.T) {
+	config := RouteAuthzConfig{Rules: []RouteRule{
+		{Path: "/admin/users", Permissions: []string{"user:admin"}},
+		{Path: "/reports/*", Permissions: []string{"report:read"}},
+	}}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/admin/users", wantStatus: http.StatusForbidden},
+		{path: "/admin/users/", wantStatus: http.StatusForbidden},
+		{path: "//admin//users", wantStatus: http.StatusForbidden},
+		{path: "//admin//users//", wantStatus: http.StatusForbidden},
+		{path: "/admin/./users", wantStatus: http.StatusBadRequest},
+		{path: "/public/../admin/users", wantStatus: http.StatusBadRequest},
+		{path: "/reports", wantStatus: http.StatusForbidden},
+		{path: "/reports/", wantStatus: http.StatusForbidden},
+		{path: "//reports//daily", wantStatus: http.StatusForbidden},
+		{path: "/admin/users-export", wantStatus: http.StatusOK},
+		{path: "/reportsarchive", wantStatus: http.StatusOK},
+		{path: "/public", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+			router := gin.New()
+			reached := false
+			router.Any("/*path", withUser(nil, []string{"user:read"}), m.RouteAuthz(config), func(c *gin.Context) {
+				reached = true
+				c.Status(http.StatusOK)
+			})
+
+			// 直接設定 URL.Path，避免 //admin 被解析為主機名稱
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v", reached)
+			}
+		})
+	}
+}
+
+func TestRouteAuthzRejectsPrefixEscape(t *testing.T) {
+	config := RouteAuthzConfig{Rules: []RouteRule{
+		{Path: "/admin/*", Permissions: []string{"admin:access"}},
+	}}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "protected", target: "/admin/secret", wantStatus: http.StatusForbidden},
+		{name: "dot dot escape", target: "/admin/../public", wantStatus: http.StatusBadRequest},
+		{name: "encoded dot dot escape", target: "/admin/%2e%2e/x", wantStatus: http.StatusBadRequest},
+		{name: "mixed case encoded escape", target: "/admin/%2E./x", wantStatus: http.StatusBadRequest},
+		{name: "trailing dot dot", target: "/admin/..", wantStatus: http.StatusBadRequest},
+		{name: "dot segment", target: "/admin/./secret", wantStatus: http.StatusBadRequest},
+		{name: "public", target: "/public", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+			router := gin.New()
+			router.Use(withUser(nil, []string{"user:read"}), m.RouteAuthz(config))
+			adminReached := false
+			router.GET("/admin/*any", func(c *gin.Context) {
+				adminReached = true
+				c.String(http.StatusOK, "ADMIN CONTENT %s", c.Param("any"))
+			})
+			router.GET("/public", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			// 直接設定 URL，避免 httptest.NewRequest 或 http.Client 先解析 . 與 ..
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			u, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.target, err)
+			}
+			req.URL = u
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %q", w.Code, tt.wantStatus, w.Body)
+			}
+			if adminReached {
+				t.Errorf("admin handler reached for %s", tt.target)
+			}
+		})
+	}
+}
+
+func TestRouteAuthzRejectsDotSegmentRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RouteAuthz accepted a rule path with .. segments")
+		}
+	}()
+	m := NewGinMiddleware(&stubAuthClient{}, zap.NewNop())
+	m.RouteAuthz(RouteAuthzConfig{Rules: []RouteRule{{Path: "/admin/../public", Permissions: []string{"admin:access"}}}})
+}
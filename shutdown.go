@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// shutdownTimeout Close 清空緩衝資料的時間上限
+const shutdownTimeout = 5 * time.Second
+
+// ShutdownReport 關閉客戶端時的緩衝資料處理結果
+type ShutdownReport struct {
+	Flushed    int                        `json:"flushed"`    // 成功送出的事件數
+	Dropped    int                        `json:"dropped"`    // 未能送出而捨棄的事件數
+	Components map[string]ComponentReport `json:"components"` // 各元件明細
+}
+
+// ComponentReport 單一元件的清空結果
+type ComponentReport struct {
+	Flushed int `json:"flushed"`
+	Dropped int `json:"dropped"`
+}
+
+// bufferedComponent 持有緩衝資料、需在關閉前清空的元件（如稽核事件、非同步指標）
+type bufferedComponent interface {
+	Name() string
+	Flush(ctx context.Context) (flushed, dropped int)
+}
+
+// registerBuffered 註冊需在 Shutdown 時清空的元件
+func (c *Client) registerBuffered(component bufferedComponent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buffered = append(c.buffered, component)
+}
+
+// Shutdown 清空所有緩衝的稽核事件與指標後關閉連線，並回傳處理結果
+// ctx 到期後尚未送出的事件計入 Dropped；重複呼叫時回傳空報告
+func (c *Client) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	report := &ShutdownReport{Components: make(map[string]ComponentReport)}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return report, nil
+	}
+	c.closed = true
+	components := c.buffered
+	c.buffered = nil
+	c.mu.Unlock()
+
+	for _, component := range components {
+		flushed, dropped := component.Flush(ctx)
+		report.Flushed += flushed
+		report.Dropped += dropped
+		report.Components[component.Name()] = ComponentReport{Flushed: flushed, Dropped: dropped}
+	}
+
+	if len(components) > 0 {
+		c.logger.Info("Auth client buffers flushed",
+			zap.Int("flushed", report.Flushed),
+			zap.Int("dropped", report.Dropped))
+	}
+
+	// 僅關閉 SDK 建立的連線，外部提供的 Redis 客戶端由呼叫端負責關閉
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// PermissionStore 用戶狀態、強制登出與動態權限的存取介面
+// 預設由 RedisStore 實作；可透過 Config.Store 注入其他後端（如 gRPC 服務）或測試替身
+//
+// 資料不存在時，Get 類方法應回傳 ErrCacheNotFound；內容無法解析時應回傳包裝 ErrCorruptCache 的錯誤
+type PermissionStore interface {
+	GetUserStatus(ctx context.Context, userID string) (*UserStatus, error)
+	// SetUserStatus 寫入用戶狀態；實作應拒絕 UpdatedAt 不晚於現有狀態的寫入並回傳 ErrStaleUserStatus
+	SetUserStatus(ctx context.Context, userID string, status UserStatus) error
+
+	// GetForceLogout 回傳強制登出時間（Unix 秒）
+	GetForceLogout(ctx context.Context, userID string) (int64, error)
+	// SetForceLogout 寫入強制登出時間；實作應忽略早於現有標記的時間，確保標記不會倒退
+	SetForceLogout(ctx context.Context, userID string, timestamp int64) error
+	ClearForceLogout(ctx context.Context, userID string) error
+
+	GetDynamicPermissions(ctx context.Context, userID string) ([]string, error)
+}
+
+// BatchPermissionStore 支援批次查詢的 PermissionStore（選用）
+// 未實作時 Client 會逐一查詢
+//
+// 回傳的 map 中，資料不存在或內容無法解析的用戶對應 nil；查詢失敗的用戶不會出現在 map 中，錯誤合併回傳
+type BatchPermissionStore interface {
+	GetUserStatuses(ctx context.Context, userIDs []string) (map[string]*UserStatus, error)
+	GetDynamicPermissionsBatch(ctx context.Context, userIDs []string) (map[string][]string, error)
+}
+
+// OnceTokenStore 可標記一次性 token 已使用的 PermissionStore（選用），供 ConsumeOnceToken 使用，RedisStore 已實作
+type OnceTokenStore interface {
+	// MarkTokenConsumed 將 jti 標記為已使用並保留 ttl；標記須為原子操作，已標記過時回傳 false
+	MarkTokenConsumed(ctx context.Context, tokenID string, ttl time.Duration) (bool, error)
+}
+
+// HealthCheckStore 可檢查連線狀態的 PermissionStore（選用），設定為 Config.Store 時由 CheckHealth 檢查，RedisStore 已實作
+type HealthCheckStore interface {
+	Ping(ctx context.Context) error
+}
+
+// PermissionAgeStore 可回報動態權限寫入時間的 PermissionStore（選用），供 GetPermissionCacheAge 使用
+type PermissionAgeStore interface {
+	// GetDynamicPermissionsUpdatedAt 回傳寫入端產生權限資料的時間；
+	// 資料未帶時間時回傳 ErrCacheTimestampMissing
+	GetDynamicPermissionsUpdatedAt(ctx context.Context, userID string) (time.Time, error)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ContextKeyTenantID SubdomainTenant 由請求子網域解析出的租戶
+const ContextKeyTenantID = "tenant_id"
+
+// SubdomainTenantConfig 以子網域區分租戶的設定
+type SubdomainTenantConfig struct {
+	// BaseDomain 基礎網域，如 app.com；acme.app.com 解析為租戶 acme，僅接受單層子網域
+	BaseDomain string
+	// RequireTenant 請求的 Host 不是租戶子網域時回應 400；預設視為無租戶並繼續處理
+	RequireTenant bool
+	// VerifyTokenTenant 已驗證的請求須帶有與子網域相同的 tenant_id 聲明，否則回應 403
+	// 需掛在 Authenticate 或 OptionalAuth 之後；未驗證的請求不檢查
+	VerifyTokenTenant bool
+}
+
+// SubdomainTenant 從 Host 的子網域解析租戶並寫入上下文（ContextKeyTenantID），可選擇與 token 的租戶比對
+// Host 由用戶端提供，單獨使用只適合路由；防止跨租戶存取須啟用 VerifyTokenTenant
+func (m *GinMiddleware) SubdomainTenant(config SubdomainTenantConfig) gin.HandlerFunc {
+	baseDomain := strings.ToLower(strings.Trim(config.BaseDomain, "."))
+	if baseDomain == "" {
+		panic("auth: SubdomainTenant requires BaseDomain")
+	}
+	suffix := "." + baseDomain
+
+	return func(c *gin.Context) {
+		tenant := tenantFromHost(c.Request.Host, suffix)
+		if tenant == "" {
+			if config.RequireTenant {
+				m.abortWithError(c, http.StatusBadRequest, ErrorResponse{
+					Success: false,
+					Code:    http.StatusBadRequest,
+					Message: "Missing tenant subdomain",
+					Error:   "BAD_REQUEST",
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+		c.Set(ContextKeyTenantID, tenant)
+
+		if config.VerifyTokenTenant {
+			if userID, ok := GetUserID(c); ok {
+				if tokenTenant := c.GetString(ContextKeyTokenTenant); tokenTenant != tenant {
+					m.logger.Warn("Token tenant does not match request tenant",
+						zap.String("user_id", userID),
+						zap.String("tenant_id", tenant),
+						zap.String("token_tenant_id", tokenTenant))
+					m.respondForbidden(c, "Token is not valid for this tenant")
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetTenantID 取得 SubdomainTenant 解析出的租戶
+func GetTenantID(c *gin.Context) (string, bool) {
+	return contextString(c, ContextKeyTenantID)
+}
+
+// tenantFromHost 取出 Host 在基礎網域之前的單層子網域，不符合時回傳空字串
+func tenantFromHost(host, suffix string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	tenant, ok := strings.CutSuffix(host, suffix)
+	if !ok || tenant == "" || strings.Contains(tenant, ".") {
+		return ""
+	}
+	return tenant
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenExtractor 從請求中取得 token 的介面
+// 回傳的 bool 表示是否成功取得非空 token
+type TokenExtractor interface {
+	Extract(c *gin.Context) (string, bool)
+}
+
+// TokenExtractorFunc 將函式轉為 TokenExtractor
+type TokenExtractorFunc func(c *gin.Context) (string, bool)
+
+// Extract 實作 TokenExtractor
+func (f TokenExtractorFunc) Extract(c *gin.Context) (string, bool) {
+	return f(c)
+}
+
+// ChainExtractors 依序嘗試多個擷取器，採用第一個取得的 token
+func ChainExtractors(extractors ...TokenExtractor) TokenExtractor {
+	return TokenExtractorFunc(func(c *gin.Context) (string, bool) {
+		return extractToken(c, extractors)
+	})
+}
+
+// HeaderExtractor 從請求標頭取得 token
+type HeaderExtractor struct {
+	Name   string // 標頭名稱，預設為 Authorization
+	Scheme string // 驗證方案（如 Bearer），為空時整個標頭值即為 token
+}
+
+// BearerExtractor 回傳讀取 Authorization: Bearer <token> 的擷取器（預設行為）
+func BearerExtractor() HeaderExtractor {
+	return HeaderExtractor{Name: "Authorization", Scheme: "Bearer"}
+}
+
+// Extract 實作 TokenExtractor
+func (e HeaderExtractor) Extract(c *gin.Context) (string, bool) {
+	name := e.Name
+	if name == "" {
+		name = "Authorization"
+	}
+
+	value := strings.TrimSpace(c.GetHeader(name))
+	if value == "" {
+		return "", false
+	}
+
+	if e.Scheme == "" {
+		return value, true
+	}
+
+	scheme, token, found := strings.Cut(value, " ")
+	if !found || !strings.EqualFold(scheme, e.Scheme) {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// CookieExtractor 從 Cookie 取得 token
+type CookieExtractor struct {
+	Name string // Cookie 名稱
+}
+
+// Extract 實作 TokenExtractor
+func (e CookieExtractor) Extract(c *gin.Context) (string, bool) {
+	token, err := c.Cookie(e.Name)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// QueryExtractor 從查詢參數取得 token
+type QueryExtractor struct {
+	Name string // 查詢參數名稱，如 token
+}
+
+// Extract 實作 TokenExtractor
+func (e QueryExtractor) Extract(c *gin.Context) (string, bool) {
+	token := c.Query(e.Name)
+	return token, token != ""
+}
+
+// extractToken 依序嘗試擷取器，回傳第一個取得的 token
+func extractToken(c *gin.Context, extractors []TokenExtractor) (string, bool) {
+	for _, extractor := range extractors {
+		if token, ok := extractor.Extract(c); ok {
+			return token, true
+		}
+	}
+	return "", false
+}
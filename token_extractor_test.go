@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newExtractorContext 以指定的請求設定建立 gin.Context
+func newExtractorContext(target string, setup func(req *http.Request)) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	if setup != nil {
+		setup(c.Request)
+	}
+	return c
+}
+
+func TestTokenExtractors(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor TokenExtractor
+		target    string
+		setup     func(req *http.Request)
+		wantToken string
+		wantOK    bool
+	}{
+		{
+			name:      "bearer",
+			extractor: BearerExtractor(),
+			setup:     func(req *http.Request) { req.Header.Set("Authorization", "Bearer abc") },
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "bearer scheme is case insensitive",
+			extractor: BearerExtractor(),
+			setup:     func(req *http.Request) { req.Header.Set("Authorization", "bearer  abc ") },
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "bearer wrong scheme",
+			extractor: BearerExtractor(),
+			setup:     func(req *http.Request) { req.Header.Set("Authorization", "Basic abc") },
+		},
+		{
+			name:      "bearer without token",
+			extractor: BearerExtractor(),
+			setup:     func(req *http.Request) { req.Header.Set("Authorization", "Bearer ") },
+		},
+		{
+			name:      "bearer missing header",
+			extractor: BearerExtractor(),
+		},
+		{
+			name:      "custom header without scheme",
+			extractor: HeaderExtractor{Name: "X-Access-Token"},
+			setup:     func(req *http.Request) { req.Header.Set("X-Access-Token", " abc ") },
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "header defaults to authorization",
+			extractor: HeaderExtractor{Scheme: "JWT"},
+			setup:     func(req *http.Request) { req.Header.Set("Authorization", "JWT abc") },
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "cookie",
+			extractor: CookieExtractor{Name: "access_token"},
+			setup:     func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "access_token", Value: "abc"}) },
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "cookie missing",
+			extractor: CookieExtractor{Name: "access_token"},
+			setup:     func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "other", Value: "abc"}) },
+		},
+		{
+			name:      "cookie empty",
+			extractor: CookieExtractor{Name: "access_token"},
+			setup:     func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "access_token", Value: ""}) },
+		},
+		{
+			name:      "query",
+			extractor: QueryExtractor{Name: "token"},
+			target:    "/?token=abc",
+			wantToken: "abc", wantOK: true,
+		},
+		{
+			name:      "query missing",
+			extractor: QueryExtractor{Name: "token"},
+			target:    "/?other=abc",
+		},
+		{
+			name:      "query empty",
+			extractor: QueryExtractor{Name: "token"},
+			target:    "/?token=",
+		},
+		{
+			name: "func",
+			extractor: TokenExtractorFunc(func(c *gin.Context) (string, bool) {
+				return c.GetHeader("X-Token"), true
+			}),
+			setup:     func(req *http.Request) { req.Header.Set("X-Token", "abc") },
+			wantToken: "abc", wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+			c := newExtractorContext(target, tt.setup)
+
+			token, ok := tt.extractor.Extract(c)
+			if ok != tt.wantOK || token != tt.wantToken {
+				t.Errorf("Extract() = (%q, %v), want (%q, %v)", token, ok, tt.wantToken, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestChainExtractorsFallbackOrder(t *testing.T) {
+	chain := ChainExtractors(
+		BearerExtractor(),
+		CookieExtractor{Name: "access_token"},
+		QueryExtractor{Name: "token"},
+	)
+
+	tests := []struct {
+		name      string
+		target    string
+		setup     func(req *http.Request)
+		wantToken string
+		wantOK    bool
+	}{
+		{
+			name:   "header wins over cookie and query",
+			target: "/?token=from-query",
+			setup: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer from-header")
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: "from-cookie"})
+			},
+			wantToken: "from-header", wantOK: true,
+		},
+		{
+			name:   "cookie wins over query",
+			target: "/?token=from-query",
+			setup: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: "from-cookie"})
+			},
+			wantToken: "from-cookie", wantOK: true,
+		},
+		{
+			name:   "malformed header falls through",
+			target: "/?token=from-query",
+			setup: func(req *http.Request) {
+				req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+			},
+			wantToken: "from-query", wantOK: true,
+		},
+		{
+			name:      "query as last resort",
+			target:    "/?token=from-query",
+			wantToken: "from-query", wantOK: true,
+		},
+		{
+			name:   "nothing found",
+			target: "/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newExtractorContext(tt.target, tt.setup)
+
+			token, ok := chain.Extract(c)
+			if ok != tt.wantOK || token != tt.wantToken {
+				t.Errorf("Extract() = (%q, %v), want (%q, %v)", token, ok, tt.wantToken, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestChainExtractorsStopsAtFirstMatch(t *testing.T) {
+	var calls []string
+	record := func(name, token string) TokenExtractor {
+		return TokenExtractorFunc(func(*gin.Context) (string, bool) {
+			calls = append(calls, name)
+			return token, token != ""
+		})
+	}
+
+	chain := ChainExtractors(record("first", ""), record("second", "abc"), record("third", "xyz"))
+	token, ok := chain.Extract(newExtractorContext("/", nil))
+	if !ok || token != "abc" {
+		t.Fatalf("Extract() = (%q, %v), want (\"abc\", true)", token, ok)
+	}
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Errorf("extractors called = %v, want [first second]", calls)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName OpenTelemetry instrumentation 名稱
+const tracerName = "github.com/Spencer810704/devops-portal-auth-sdk"
+
+// span 屬性，絕不記錄原始 token
+const (
+	attrUserID           = attribute.Key("user_id")
+	attrCacheHit         = attribute.Key("auth.cache_hit")
+	attrNegativeCacheHit = attribute.Key("auth.negative_cache_hit")
+	attrIsActive         = attribute.Key("auth.is_active")
+	attrForceLogout      = attribute.Key("auth.force_logout")
+	attrDegraded         = attribute.Key("auth.degraded")
+)
+
+// newTracer 由設定的 TracerProvider 建立 tracer，未設定時使用全域 provider（預設為 no-op）
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// startStoreSpan 開始一次儲存查詢的 span
+func (c *Client) startStoreSpan(ctx context.Context, name, userID string) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrUserID.String(userID)))
+}
+
+// endStoreSpan 依查詢結果記錄快取命中與錯誤後結束 span
+// ErrCacheNotFound 視為未命中而非錯誤
+func endStoreSpan(span trace.Span, err error) {
+	switch {
+	case err == nil:
+		span.SetAttributes(attrCacheHit.Bool(true))
+	case errors.Is(err, ErrCacheNotFound):
+		span.SetAttributes(attrCacheHit.Bool(false))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
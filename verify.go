@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrConfigMismatch 客戶端設定與 Auth 服務實際簽發的內容不一致
+var ErrConfigMismatch = errors.New("config does not match auth service")
+
+// discoveryDocument OpenID Connect discovery 文件中用到的欄位
+type discoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// VerifyAgainstAuthService 比對客戶端設定與 Auth 服務的 discovery 文件（{AuthServiceURL}/.well-known/openid-configuration），
+// 供部署時及早發現設定漂移：
+//   - issuer 須通過 Issuer/IssuerPattern 檢查
+//   - 服務宣告的簽名演算法須與 SigningAlgorithms 有交集
+//   - 使用 PublicKeyPath 時，公鑰須出現在服務的 JWKS 中（設定 KeyID 時須對應同一個 kid）
+//   - 使用 JWKSURL 時，服務的 JWKS 須至少有一把與 JWKSURL 相同 kid 的金鑰
+//
+// 所有不一致合併回傳，每項皆包裝 ErrConfigMismatch；無法取得文件時回傳一般錯誤
+// discovery 文件不含受眾資訊，ExpectedAudience 無法以此驗證
+func (c *Client) VerifyAgainstAuthService(ctx context.Context) error {
+	if c.config.AuthServiceURL == "" {
+		return errors.New("auth service URL is not configured")
+	}
+
+	base := strings.TrimRight(c.config.AuthServiceURL, "/")
+	doc, err := c.fetchDiscovery(ctx, base+"/.well-known/openid-configuration")
+	if err != nil {
+		return err
+	}
+
+	var mismatches []error
+
+	if !c.isIssuerAllowed(doc.Issuer) {
+		mismatches = append(mismatches, fmt.Errorf("%w: auth service issuer is %q, but Issuer=%q IssuerPattern=%q does not accept it",
+			ErrConfigMismatch, doc.Issuer, c.config.Issuer, c.config.IssuerPattern))
+	}
+
+	if len(doc.IDTokenSigningAlgValuesSupported) > 0 && !intersects(doc.IDTokenSigningAlgValuesSupported, c.algorithms) {
+		mismatches = append(mismatches, fmt.Errorf("%w: auth service signs with %v, but SigningAlgorithms allows only %v",
+			ErrConfigMismatch, doc.IDTokenSigningAlgValuesSupported, c.algorithms))
+	}
+
+	if doc.JWKSURI == "" {
+		mismatches = append(mismatches, fmt.Errorf("%w: discovery document has no jwks_uri, keys cannot be compared", ErrConfigMismatch))
+		return errors.Join(mismatches...)
+	}
+
+	jwksURL, err := resolveReference(base, doc.JWKSURI)
+	if err != nil {
+		return fmt.Errorf("invalid jwks_uri %q: %w", doc.JWKSURI, err)
+	}
+	serviceKeys, err := fetchJWKS(ctx, c.httpClient, jwksURL, c.logger)
+	if err != nil {
+		return fmt.Errorf("failed to fetch auth service JWKS: %w", err)
+	}
+
+	if c.jwks != nil {
+		if !c.sharesKeyID(serviceKeys) {
+			mismatches = append(mismatches, fmt.Errorf("%w: none of the key IDs published at %s are served by JWKSURL %s",
+				ErrConfigMismatch, jwksURL, c.config.JWKSURL))
+		}
+	} else if mismatch := c.checkStaticKey(serviceKeys, jwksURL); mismatch != nil {
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return errors.Join(mismatches...)
+}
+
+// fetchDiscovery 取得 discovery 文件
+func (c *Client) fetchDiscovery(ctx context.Context, endpoint string) (*discoveryDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery endpoint returned status %d", resp.StatusCode)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+	return &doc, nil
+}
+
+// checkStaticKey 檢查 PublicKeyPath 的公鑰是否由服務發布
+func (c *Client) checkStaticKey(serviceKeys map[string]interface{}, jwksURL string) error {
+	if c.config.KeyID != "" {
+		key, ok := serviceKeys[c.config.KeyID]
+		if !ok {
+			return fmt.Errorf("%w: KeyID %q is not published at %s (rotated?)", ErrConfigMismatch, c.config.KeyID, jwksURL)
+		}
+		if !publicKeysEqual(c.publicKey, key) {
+			return fmt.Errorf("%w: key %q published at %s differs from PublicKeyPath %s",
+				ErrConfigMismatch, c.config.KeyID, jwksURL, c.config.PublicKeyPath)
+		}
+		return nil
+	}
+
+	for _, key := range serviceKeys {
+		if publicKeysEqual(c.publicKey, key) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: PublicKeyPath %s matches none of the keys published at %s (rotated?)",
+		ErrConfigMismatch, c.config.PublicKeyPath, jwksURL)
+}
+
+// sharesKeyID 檢查 JWKSURL 目前載入的金鑰與服務發布的金鑰是否有相同 kid
+func (c *Client) sharesKeyID(serviceKeys map[string]interface{}) bool {
+	for kid := range serviceKeys {
+		if _, ok := c.jwks.lookup(kid); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// publicKeysEqual 比對兩把公鑰（RSA、ECDSA、Ed25519 皆實作 Equal）
+func publicKeysEqual(a, b interface{}) bool {
+	eq, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && eq.Equal(b)
+}
+
+// resolveReference 以 base 解析相對的 URL
+func resolveReference(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base + "/")
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
+
+// intersects 判斷兩個字串集合是否有交集
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}